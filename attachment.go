@@ -0,0 +1,131 @@
+package mediatypes
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Disposition is the presentation of a message part, as defined by RFC 2183.
+type Disposition string
+
+const (
+	// DispositionInline means the part is displayed with the message.
+	DispositionInline Disposition = "inline"
+
+	// DispositionAttachment means the part is displayed only on request.
+	DispositionAttachment Disposition = "attachment"
+)
+
+// inlineMediaTypes holds the media types that mail clients display safely
+// with a message. Formats that can run script, such as HTML and SVG, are left
+// out.
+var inlineMediaTypes = map[string]bool{
+	"image/gif":  true,
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+	"text/plain": true,
+}
+
+// Attachment describes a file attached to an email message.
+type Attachment struct {
+	// Filename is the name of the file, without any directory.
+	Filename string
+
+	// MediaType is the media type of the file.
+	MediaType MediaType
+
+	// Disposition is how the file is presented.
+	Disposition Disposition
+}
+
+// NewAttachment returns an attachment for a file with the given name and, if
+// available, content. The media type is resolved from the extension of the
+// file name and the content, as Resolve does, and is
+// "application/octet-stream" if neither identifies it. Images that mail
+// clients display safely and plain text are inline; everything else is an
+// attachment. Any directory is removed from the file name.
+func NewAttachment(filename string, data []byte) Attachment {
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	a := Attachment{
+		Filename:    baseName(filename),
+		MediaType:   lookup("application/octet-stream"),
+		Disposition: DispositionAttachment,
+	}
+	if candidates := Resolve(Evidence{Filename: a.Filename, Prefix: data}); len(candidates) > 0 {
+		a.MediaType = candidates[0].MediaType
+	}
+	if inlineMediaTypes[strings.ToLower(a.MediaType.name)] {
+		a.Disposition = DispositionInline
+	}
+	return a
+}
+
+// ContentType returns the value of the Content-Type header of the
+// attachment. It carries the file name in the name parameter for mail
+// clients that do not read the Content-Disposition header.
+func (a *Attachment) ContentType() string {
+	m := a.MediaType
+	if a.Filename != "" {
+		m = m.WithParam("name", a.Filename)
+	}
+	return m.ContentType()
+}
+
+// ContentDisposition returns the value of the Content-Disposition header of
+// the attachment, as defined by RFC 2183, such as
+// `attachment; filename="report.pdf"`. File names with non-ASCII characters
+// are encoded as described by RFC 2231 and also given in ASCII for clients
+// that do not support it.
+func (a *Attachment) ContentDisposition() string {
+	disposition := a.Disposition
+	if disposition == "" {
+		disposition = DispositionAttachment
+	}
+	return formatDisposition(disposition, a.Filename, false)
+}
+
+// formatDisposition returns a Content-Disposition value with the file name.
+// The filename parameter holds an ASCII form of the name; if that differs
+// from the name, or if extended is set, the filename* parameter holds the
+// name encoded as described by RFC 2231 and RFC 8187.
+func formatDisposition(disposition Disposition, filename string, extended bool) string {
+	var b strings.Builder
+	b.WriteString(string(disposition))
+	if filename == "" {
+		return b.String()
+	}
+	fallback := asciiFilename(filename)
+	b.WriteString("; filename=")
+	b.WriteString(quoteString(fallback))
+	if extended || fallback != filename {
+		b.WriteString("; filename*=")
+		b.WriteString(encodeExtendedValue(filename))
+	}
+	return b.String()
+}
+
+// asciiFilename returns filename with characters outside of printable ASCII
+// replaced by underscores.
+func asciiFilename(filename string) string {
+	var b strings.Builder
+	for _, r := range filename {
+		if r < 0x20 || r >= 0x7f || r == utf8.RuneError {
+			b.WriteByte('_')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// baseName returns the last element of a path that may use either slashes
+// or backslashes as separators.
+func baseName(filename string) string {
+	if i := strings.LastIndexAny(filename, `/\`); i >= 0 {
+		filename = filename[i+1:]
+	}
+	return filename
+}
@@ -0,0 +1,85 @@
+package mediatypes
+
+import (
+	"testing"
+)
+
+func TestNewAttachment(t *testing.T) {
+	tests := []struct {
+		name                   string
+		filename               string
+		data                   string
+		wantContentType        string
+		wantContentDisposition string
+	}{
+		{
+			name:                   "document",
+			filename:               "report.pdf",
+			data:                   "%PDF-1.7\n",
+			wantContentType:        "application/pdf; name=report.pdf",
+			wantContentDisposition: `attachment; filename="report.pdf"`,
+		},
+		{
+			name:                   "image",
+			filename:               "chart.png",
+			data:                   "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR",
+			wantContentType:        "image/png; name=chart.png",
+			wantContentDisposition: `inline; filename="chart.png"`,
+		},
+		{
+			name:                   "text with charset",
+			filename:               "notes.txt",
+			data:                   "Caf\xc3\xa9 au lait",
+			wantContentType:        "text/plain; charset=utf-8; name=notes.txt",
+			wantContentDisposition: `inline; filename="notes.txt"`,
+		},
+		{
+			name:                   "script-capable image",
+			filename:               "logo.svg",
+			wantContentType:        "image/svg+xml; name=logo.svg",
+			wantContentDisposition: `attachment; filename="logo.svg"`,
+		},
+		{
+			name:                   "content outweighs extension",
+			filename:               "photo.jpg",
+			data:                   "%PDF-1.7\n",
+			wantContentType:        "application/pdf; name=photo.jpg",
+			wantContentDisposition: `attachment; filename="photo.jpg"`,
+		},
+		{
+			name:                   "non-ASCII name",
+			filename:               "Bericht für März.pdf",
+			wantContentType:        "application/pdf; name*=UTF-8''Bericht%20f%C3%BCr%20M%C3%A4rz.pdf",
+			wantContentDisposition: `attachment; filename="Bericht f_r M_rz.pdf"; filename*=UTF-8''Bericht%20f%C3%BCr%20M%C3%A4rz.pdf`,
+		},
+		{
+			name:                   "quoted name with directory",
+			filename:               `C:\reports\"Q1".pdf`,
+			wantContentType:        `application/pdf; name="\"Q1\".pdf"`,
+			wantContentDisposition: `attachment; filename="\"Q1\".pdf"`,
+		},
+		{
+			name:                   "unknown",
+			filename:               "data",
+			wantContentType:        "application/octet-stream; name=data",
+			wantContentDisposition: `attachment; filename="data"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var data []byte
+				if tt.data != "" {
+					data = []byte(tt.data)
+				}
+				a := NewAttachment(tt.filename, data)
+				if got := a.ContentType(); got != tt.wantContentType {
+					t.Errorf("ContentType() = %q, want %q", got, tt.wantContentType)
+				}
+				if got := a.ContentDisposition(); got != tt.wantContentDisposition {
+					t.Errorf("ContentDisposition() = %q, want %q", got, tt.wantContentDisposition)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,178 @@
+package mediatypes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charset describes the character encoding of text.
+type Charset struct {
+	// Name is the IANA name of the encoding in lower case, such as "utf-8",
+	// "utf-16le" or "windows-1252", or empty if the content is not text.
+	Name string
+
+	// BOM is true if the encoding was identified by a byte order mark.
+	BOM bool
+}
+
+// byteOrderMarks lists the byte order marks, longest first so that the
+// UTF-32LE mark is not mistaken for the UTF-16LE one.
+var byteOrderMarks = []struct {
+	bom  []byte
+	name string
+}{
+	{bom: []byte{0xff, 0xfe, 0x00, 0x00}, name: "utf-32le"},
+	{bom: []byte{0x00, 0x00, 0xfe, 0xff}, name: "utf-32be"},
+	{bom: []byte{0xef, 0xbb, 0xbf}, name: "utf-8"},
+	{bom: []byte{0xfe, 0xff}, name: "utf-16be"},
+	{bom: []byte{0xff, 0xfe}, name: "utf-16le"},
+}
+
+// DetectCharset returns the character encoding of text. It recognizes byte
+// order marks, UTF-16 and UTF-32 without a byte order mark from the pattern
+// of null bytes, and valid UTF-8. Other text is reported as "windows-1252"
+// if it uses the printable characters Windows-1252 defines in the 0x80 to
+// 0x9F range, and "iso-8859-1" otherwise. An incomplete character at the end
+// of data is ignored, so a prefix of a larger text can be passed. If data
+// contains control characters that do not occur in text, the name is empty.
+func DetectCharset(data []byte) Charset {
+	for _, b := range byteOrderMarks {
+		if bytes.HasPrefix(data, b.bom) {
+			return Charset{Name: b.name, BOM: true}
+		}
+	}
+	if name := detectWideCharset(data); name != "" {
+		return Charset{Name: name}
+	}
+
+	if hasBinaryBytes(data) {
+		return Charset{}
+	}
+
+	// Ignore a character that is cut short by the end of the data.
+	valid := data
+	for i := 1; i < utf8.UTFMax && i <= len(valid); i++ {
+		if utf8.RuneStart(valid[len(valid)-i]) {
+			if !utf8.FullRune(valid[len(valid)-i:]) {
+				valid = valid[:len(valid)-i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(valid) {
+		return Charset{Name: "utf-8"}
+	}
+	for _, c := range data {
+		if c >= 0x80 && c <= 0x9f {
+			if c == 0x81 || c == 0x8d || c == 0x8f || c == 0x90 || c == 0x9d {
+				// Undefined in Windows-1252, and a C1 control in ISO-8859-1.
+				return Charset{}
+			}
+			return Charset{Name: "windows-1252"}
+		}
+	}
+	return Charset{Name: "iso-8859-1"}
+}
+
+// detectWideCharset returns the name of a UTF-16 or UTF-32 encoding if the
+// null bytes in data follow the pattern of mostly ASCII text in it, or an
+// empty string otherwise.
+func detectWideCharset(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	var zeros [4]int
+	n := len(data) &^ 3
+	for i := 0; i < n; i++ {
+		if data[i] == 0 {
+			zeros[i%4]++
+		}
+	}
+	units := n / 4
+	mostly := func(count int) bool {
+		return count*10 >= units*9
+	}
+	few := func(count int) bool {
+		return count*10 <= units
+	}
+	switch {
+	case few(zeros[0]) && mostly(zeros[1]) && mostly(zeros[2]) && mostly(zeros[3]):
+		return "utf-32le"
+	case mostly(zeros[0]) && mostly(zeros[1]) && mostly(zeros[2]) && few(zeros[3]):
+		return "utf-32be"
+	case few(zeros[0]) && few(zeros[2]) && mostly(zeros[1]) && mostly(zeros[3]):
+		return "utf-16le"
+	case mostly(zeros[0]) && mostly(zeros[2]) && few(zeros[1]) && few(zeros[3]):
+		return "utf-16be"
+	}
+	return ""
+}
+
+// isWideCharset returns true if name is a UTF-16 or UTF-32 encoding.
+func isWideCharset(name string) bool {
+	return strings.HasPrefix(name, "utf-16") || strings.HasPrefix(name, "utf-32")
+}
+
+// hasBinaryBytes returns true if data contains control characters that do
+// not occur in text.
+func hasBinaryBytes(data []byte) bool {
+	for _, c := range data {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' && c != 0x1b {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeText returns text in the given charset as UTF-8, without a byte order
+// mark. Only the Unicode encodings are converted; other text is returned as
+// is.
+func decodeText(data []byte, charset Charset) []byte {
+	var (
+		order binary.ByteOrder
+		width int
+	)
+	switch charset.Name {
+	case "utf-8":
+		return bytes.TrimPrefix(data, []byte{0xef, 0xbb, 0xbf})
+	case "utf-16le":
+		order, width = binary.LittleEndian, 2
+	case "utf-16be":
+		order, width = binary.BigEndian, 2
+	case "utf-32le":
+		order, width = binary.LittleEndian, 4
+	case "utf-32be":
+		order, width = binary.BigEndian, 4
+	default:
+		return data
+	}
+	if charset.BOM {
+		data = data[width:]
+	}
+
+	var runes []rune
+	if width == 2 {
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[2*i:])
+		}
+		runes = utf16.Decode(units)
+	} else {
+		runes = make([]rune, len(data)/4)
+		for i := range runes {
+			runes[i] = rune(order.Uint32(data[4*i:]))
+		}
+	}
+	return []byte(string(runes))
+}
+
+// isTextual returns true if the media type is text that can carry a charset
+// parameter.
+func isTextual(m MediaType) bool {
+	name := strings.ToLower(m.name)
+	return strings.HasPrefix(name, "text/") || name == "application/xml" ||
+		m.format == "text/xml" || m.format == "text/plain"
+}
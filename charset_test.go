@@ -0,0 +1,73 @@
+package mediatypes
+
+import (
+	"testing"
+)
+
+func TestDetectCharset(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Charset
+	}{
+		{name: "utf-8 bom", data: "\xef\xbb\xbfa,b", want: Charset{Name: "utf-8", BOM: true}},
+		{name: "utf-16le bom", data: "\xff\xfea\x00", want: Charset{Name: "utf-16le", BOM: true}},
+		{name: "utf-16be bom", data: "\xfe\xff\x00a", want: Charset{Name: "utf-16be", BOM: true}},
+		{name: "utf-32le bom", data: "\xff\xfe\x00\x00a\x00\x00\x00", want: Charset{Name: "utf-32le", BOM: true}},
+		{name: "utf-32be bom", data: "\x00\x00\xfe\xff\x00\x00\x00a", want: Charset{Name: "utf-32be", BOM: true}},
+		{name: "utf-16le", data: "a\x00,\x00b\x00\n\x00", want: Charset{Name: "utf-16le"}},
+		{name: "utf-16be", data: "\x00a\x00,\x00b\x00\n", want: Charset{Name: "utf-16be"}},
+		{name: "utf-32le", data: "a\x00\x00\x00b\x00\x00\x00", want: Charset{Name: "utf-32le"}},
+		{name: "utf-32be", data: "\x00\x00\x00a\x00\x00\x00b", want: Charset{Name: "utf-32be"}},
+		{name: "ascii", data: "hello, world\n", want: Charset{Name: "utf-8"}},
+		{name: "utf-8", data: "caf\xc3\xa9", want: Charset{Name: "utf-8"}},
+		{name: "truncated utf-8", data: "caf\xc3\xa9 \xe2\x82", want: Charset{Name: "utf-8"}},
+		{name: "windows-1252", data: "\x93quoted\x94 caf\xe9", want: Charset{Name: "windows-1252"}},
+		{name: "iso-8859-1", data: "caf\xe9 au lait", want: Charset{Name: "iso-8859-1"}},
+		{name: "undefined in windows-1252", data: "\x81caf\xe9 au lait", want: Charset{}},
+		{name: "binary", data: "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR", want: Charset{}},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if got := DetectCharset([]byte(tt.data)); got != tt.want {
+					t.Errorf("DetectCharset() = %+v, want %+v", got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestDetect_Charset(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantName    string
+		wantCharset string
+	}{
+		{name: "utf-8", data: "a,b\n1,2\n3,4\n", wantName: "text/csv", wantCharset: "utf-8"},
+		{name: "utf-16le without bom", data: "a\x00,\x00b\x00\n\x00", wantName: "text/plain", wantCharset: "utf-16le"},
+		{
+			name:        "utf-16be xml",
+			data:        "\xfe\xff\x00<\x00s\x00v\x00g\x00 \x00x\x00m\x00l\x00n\x00s\x00=\x00\"\x00h\x00t\x00t\x00p\x00:\x00/\x00/\x00w\x00w\x00w\x00.\x00w\x003\x00.\x00o\x00r\x00g\x00/\x002\x000\x000\x000\x00/\x00s\x00v\x00g\x00\"\x00/\x00>",
+			wantName:    "image/svg+xml",
+			wantCharset: "utf-16be",
+		},
+		{name: "windows-1252", data: "\x93quoted\x94", wantName: "text/plain", wantCharset: "windows-1252"},
+		{name: "json has no charset", data: `{"a": 1}`, wantName: "application/json"},
+		{name: "binary", data: "GIF89a\x01\x00\x01\x00", wantName: "image/gif"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got := Detect([]byte(tt.data))
+				if got.Name() != tt.wantName || got.Param("charset") != tt.wantCharset {
+					t.Errorf(
+						"Detect() = %s; charset=%s, want %s; charset=%s",
+						got.Name(), got.Param("charset"), tt.wantName, tt.wantCharset,
+					)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,119 @@
+// Command mediatypes inspects files using the media type registry.
+//
+// Usage:
+//
+//	mediatypes inventory [flags] dir
+//
+// The inventory subcommand walks a directory and reports the media types of
+// its files, files whose extension disagrees with their content, files of
+// unknown type, and files that can run code.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+
+	"github.com/wernerstrydom/go-mediatypes"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command with the given arguments and returns the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	switch args[0] {
+	case "inventory":
+		return inventory(ctx, args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	}
+	fmt.Fprintf(stderr, "mediatypes: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+// usage writes the usage of the command.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: mediatypes <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  inventory  report the media types of the files in a directory")
+}
+
+// inventory runs the inventory subcommand.
+func inventory(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("inventory", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "json", "report `format`: json or csv")
+	output := flags.String("o", "", "write the report to `file` instead of standard output")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of files classified concurrently")
+	progress := flags.Bool("progress", false, "report progress on standard error")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mediatypes inventory [flags] dir")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 || (*format != "json" && *format != "csv") {
+		flags.Usage()
+		return 2
+	}
+
+	opts := []mediatypes.InventoryOption{mediatypes.WithWorkers(*workers)}
+	if *progress {
+		opts = append(
+			opts, mediatypes.WithProgress(
+				func(p mediatypes.Progress) {
+					fmt.Fprintf(stderr, "\r%d files, %d bytes", p.Files, p.Bytes)
+				},
+			),
+		)
+	}
+	report, err := mediatypes.Inventory(ctx, os.DirFS(flags.Arg(0)), opts...)
+	if *progress {
+		fmt.Fprintln(stderr)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "mediatypes: %v\n", err)
+		return 1
+	}
+
+	if *output == "" {
+		err = writeReport(report, *format, stdout)
+	} else {
+		var f *os.File
+		if f, err = os.Create(*output); err == nil {
+			err = writeReport(report, *format, f)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "mediatypes: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeReport writes the report in the given format.
+func writeReport(report *mediatypes.InventoryReport, format string, w io.Writer) error {
+	if format == "csv" {
+		return report.WriteCSV(w)
+	}
+	return report.WriteJSON(w)
+}
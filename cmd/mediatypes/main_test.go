@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"report.pdf": "%PDF-1.7\n",
+		"photo.jpg":  "%PDF-1.7\n",
+		"notes.txt":  "hello\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(t.TempDir(), "report.csv")
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "json", args: []string{"inventory", dir}, wantStdout: `"mismatches": 1`},
+		{name: "csv", args: []string{"inventory", "-format", "csv", dir}, wantStdout: "photo.jpg,9,application/pdf,image/jpeg,application/pdf,true,false,false,"},
+		{name: "output", args: []string{"inventory", "-format", "csv", "-o", output, dir}},
+		{name: "progress", args: []string{"inventory", "-progress", "-workers", "1", dir}, wantStderr: "3 files, 24 bytes"},
+		{name: "missing directory", args: []string{"inventory", filepath.Join(dir, "missing")}, wantCode: 1, wantStderr: "mediatypes:"},
+		{name: "invalid format", args: []string{"inventory", "-format", "xml", dir}, wantCode: 2, wantStderr: "usage:"},
+		{name: "no arguments", args: nil, wantCode: 2, wantStderr: "usage:"},
+		{name: "unknown command", args: []string{"frobnicate"}, wantCode: 2, wantStderr: "unknown command"},
+		{name: "help", args: []string{"help"}, wantStdout: "inventory"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var stdout, stderr bytes.Buffer
+				code := run(context.Background(), tt.args, &stdout, &stderr)
+				if code != tt.wantCode {
+					t.Errorf("run() = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+				}
+				if !strings.Contains(stdout.String(), tt.wantStdout) {
+					t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+				}
+				if !strings.Contains(stderr.String(), tt.wantStderr) {
+					t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+				}
+			},
+		)
+	}
+
+	data, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("report was not written: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "path,size,media_type") {
+		t.Errorf("report = %q", data)
+	}
+}
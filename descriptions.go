@@ -0,0 +1,242 @@
+package mediatypes
+
+import (
+	"strings"
+)
+
+// description holds the human-readable names of a media type.
+type description struct {
+	// text is a description such as "Microsoft Excel spreadsheet (OOXML)".
+	text string
+
+	// displayName is a short name such as "Excel".
+	displayName string
+}
+
+// Description returns a human-readable description of the media type, such
+// as "PDF document". If no description is known, one is derived from the
+// name.
+func (m MediaType) Description() string {
+	if d, ok := descriptions[m.name]; ok && d.text != "" {
+		return d.text
+	}
+	return deriveDescription(m.name)
+}
+
+// DisplayName returns a short name for the media type, such as "PDF". If no
+// display name is known, one is derived from the subtype.
+func (m MediaType) DisplayName() string {
+	if d, ok := descriptions[m.name]; ok && d.displayName != "" {
+		return d.displayName
+	}
+	return deriveDisplayName(m.name)
+}
+
+// topLevelNouns maps top-level types to the noun used in derived
+// descriptions.
+var topLevelNouns = map[string]string{
+	"application": "file",
+	"audio":       "audio",
+	"chemical":    "chemical data",
+	"font":        "font",
+	"image":       "image",
+	"message":     "message",
+	"model":       "3D model",
+	"multipart":   "multipart message",
+	"text":        "text",
+	"video":       "video",
+}
+
+// suffixNames maps structured syntax suffixes to the name used in derived
+// descriptions.
+var suffixNames = map[string]string{
+	"cbor":        "CBOR",
+	"der":         "DER",
+	"fastinfoset": "Fast Infoset",
+	"gzip":        "gzip",
+	"json":        "JSON",
+	"jwt":         "JWT",
+	"sqlite3":     "SQLite",
+	"wbxml":       "WBXML",
+	"xml":         "XML",
+	"yaml":        "YAML",
+	"zip":         "ZIP",
+}
+
+// deriveDescription derives a description such as "GIF image" or
+// "Collection (JSON) file" from a media type name.
+func deriveDescription(name string) string {
+	typ := name
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		typ = name[:i]
+	}
+	description := deriveDisplayName(name)
+	if i := strings.LastIndexByte(name, '+'); i >= 0 {
+		if suffix, ok := suffixNames[strings.ToLower(name[i+1:])]; ok {
+			description += " (" + suffix + ")"
+		}
+	}
+	if noun, ok := topLevelNouns[strings.ToLower(typ)]; ok {
+		description += " " + noun
+	}
+	return description
+}
+
+// deriveDisplayName derives a display name from the subtype of a media type
+// name, dropping the vendor, personal and unregistered prefixes and the
+// structured syntax suffix. Short subtypes such as "gif" are treated as
+// acronyms and upper-cased; others are title-cased.
+func deriveDisplayName(name string) string {
+	subtype := name
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		subtype = name[i+1:]
+	}
+	if i := strings.LastIndexByte(subtype, '+'); i > 0 {
+		subtype = subtype[:i]
+	}
+	for _, prefix := range []string{"vnd.", "prs.", "x.", "x-"} {
+		if len(subtype) > len(prefix) && strings.EqualFold(subtype[:len(prefix)], prefix) {
+			subtype = subtype[len(prefix):]
+			break
+		}
+	}
+
+	words := strings.FieldsFunc(
+		subtype, func(r rune) bool {
+			return r == '.' || r == '-' || r == '_'
+		},
+	)
+	if len(words) == 1 && len(subtype) <= 4 {
+		return strings.ToUpper(subtype)
+	}
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// descriptions holds the known descriptions, keyed by media type name. Unlike
+// the media type table, it is curated by hand for common media types; the
+// others get descriptions derived from their names.
+var descriptions = map[string]description{
+	"application/activity+json":                       {text: "Activity Streams document", displayName: "Activity Streams"},
+	"application/atom+xml":                            {text: "Atom syndication feed", displayName: "Atom"},
+	"application/dash+xml":                            {text: "MPEG-DASH manifest", displayName: "DASH"},
+	"application/dicom":                               {text: "DICOM medical image", displayName: "DICOM"},
+	"application/epub+zip":                            {text: "EPUB document", displayName: "EPUB"},
+	"application/gpx+xml":                             {text: "GPS Exchange Format document", displayName: "GPX"},
+	"application/gzip":                                {text: "Gzip archive", displayName: "Gzip"},
+	"application/java-archive":                        {text: "Java archive", displayName: "JAR"},
+	"application/java-vm":                             {text: "Java class file", displayName: "Java class"},
+	"application/javascript":                          {text: "JavaScript program", displayName: "JavaScript"},
+	"application/json":                                {text: "JSON document", displayName: "JSON"},
+	"application/json-patch+json":                     {text: "JSON Patch document", displayName: "JSON Patch"},
+	"application/ld+json":                             {text: "JSON-LD document", displayName: "JSON-LD"},
+	"application/manifest+json":                       {text: "Web application manifest", displayName: "Web manifest"},
+	"application/mathml+xml":                          {text: "MathML document", displayName: "MathML"},
+	"application/merge-patch+json":                    {text: "JSON Merge Patch document", displayName: "JSON Merge Patch"},
+	"application/msword":                              {text: "Microsoft Word document", displayName: "Word"},
+	"application/octet-stream":                        {text: "Binary data", displayName: "Binary"},
+	"application/ogg":                                 {text: "Ogg multimedia file", displayName: "Ogg"},
+	"application/pdf":                                 {text: "PDF document", displayName: "PDF"},
+	"application/postscript":                          {text: "PostScript document", displayName: "PostScript"},
+	"application/problem+json":                        {text: "HTTP problem details", displayName: "Problem Details"},
+	"application/rss+xml":                             {text: "RSS summary", displayName: "RSS"},
+	"application/rtf":                                 {text: "RTF document", displayName: "RTF"},
+	"application/soap+xml":                            {text: "SOAP message", displayName: "SOAP"},
+	"application/sql":                                 {text: "SQL code", displayName: "SQL"},
+	"application/vnd.android.package-archive":         {text: "Android package", displayName: "APK"},
+	"application/vnd.apple.mpegurl":                   {text: "HTTP Live Streaming playlist", displayName: "HLS playlist"},
+	"application/vnd.debian.binary-package":           {text: "Debian package", displayName: "Debian package"},
+	"application/vnd.geo+json":                        {text: "GeoJSON geospatial data", displayName: "GeoJSON"},
+	"application/vnd.google-earth.kml+xml":            {text: "KML geographic data", displayName: "KML"},
+	"application/vnd.ms-cab-compressed":               {text: "Microsoft Cabinet archive", displayName: "CAB"},
+	"application/vnd.ms-excel":                        {text: "Microsoft Excel spreadsheet", displayName: "Excel"},
+	"application/vnd.ms-fontobject":                   {text: "Embedded OpenType font", displayName: "EOT"},
+	"application/vnd.ms-powerpoint":                   {text: "Microsoft PowerPoint presentation", displayName: "PowerPoint"},
+	"application/vnd.oasis.opendocument.presentation": {text: "ODP presentation", displayName: "ODP"},
+	"application/vnd.oasis.opendocument.spreadsheet":  {text: "ODS spreadsheet", displayName: "ODS"},
+	"application/vnd.oasis.opendocument.text":         {text: "ODT document", displayName: "ODT"},
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": {text: "Microsoft PowerPoint presentation (OOXML)", displayName: "PowerPoint"},
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         {text: "Microsoft Excel spreadsheet (OOXML)", displayName: "Excel"},
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   {text: "Microsoft Word document (OOXML)", displayName: "Word"},
+	"application/vnd.rar":               {text: "RAR archive", displayName: "RAR"},
+	"application/vnd.sqlite3":           {text: "SQLite3 database", displayName: "SQLite"},
+	"application/vnd.visio":             {text: "Microsoft Visio document", displayName: "Visio"},
+	"application/wasm":                  {text: "WebAssembly binary", displayName: "WebAssembly"},
+	"application/x-7z-compressed":       {text: "7-zip archive", displayName: "7z"},
+	"application/x-apple-diskimage":     {text: "Apple disk image", displayName: "DMG"},
+	"application/x-bzip2":               {text: "Bzip2 archive", displayName: "Bzip2"},
+	"application/x-debian-package":      {text: "Debian package", displayName: "Debian package"},
+	"application/x-iso9660-image":       {text: "Raw CD image", displayName: "ISO"},
+	"application/x-msdownload":          {text: "Windows executable", displayName: "EXE"},
+	"application/x-msi":                 {text: "Windows Installer package", displayName: "MSI"},
+	"application/x-rar-compressed":      {text: "RAR archive", displayName: "RAR"},
+	"application/x-rpm":                 {text: "RPM package", displayName: "RPM"},
+	"application/x-sh":                  {text: "Shell script", displayName: "Shell script"},
+	"application/x-shockwave-flash":     {text: "Shockwave Flash file", displayName: "Flash"},
+	"application/x-tar":                 {text: "Tar archive", displayName: "Tar"},
+	"application/x-www-form-urlencoded": {text: "URL-encoded form data", displayName: "Form data"},
+	"application/x-xpinstall":           {text: "XPInstall installer module", displayName: "XPI"},
+	"application/x-xz":                  {text: "XZ archive", displayName: "XZ"},
+	"application/xhtml+xml":             {text: "XHTML page", displayName: "XHTML"},
+	"application/xml":                   {text: "XML document", displayName: "XML"},
+	"application/xslt+xml":              {text: "XSLT stylesheet", displayName: "XSLT"},
+	"application/zip":                   {text: "Zip archive", displayName: "Zip"},
+	"application/zstd":                  {text: "Zstandard archive", displayName: "Zstandard"},
+	"audio/aac":                         {text: "AAC audio", displayName: "AAC"},
+	"audio/flac":                        {text: "FLAC audio", displayName: "FLAC"},
+	"audio/midi":                        {text: "MIDI audio", displayName: "MIDI"},
+	"audio/mp4":                         {text: "MPEG-4 audio", displayName: "MPEG-4 audio"},
+	"audio/mpeg":                        {text: "MP3 audio", displayName: "MP3"},
+	"audio/ogg":                         {text: "Ogg audio", displayName: "Ogg"},
+	"audio/wav":                         {text: "WAV audio", displayName: "WAV"},
+	"audio/webm":                        {text: "WebM audio", displayName: "WebM"},
+	"audio/x-aiff":                      {text: "AIFF/Amiga/Mac audio", displayName: "AIFF"},
+	"audio/x-flac":                      {text: "FLAC audio", displayName: "FLAC"},
+	"audio/x-wav":                       {text: "WAV audio", displayName: "WAV"},
+	"font/otf":                          {text: "OpenType font", displayName: "OpenType"},
+	"font/ttf":                          {text: "TrueType font", displayName: "TrueType"},
+	"font/woff":                         {text: "WOFF font", displayName: "WOFF"},
+	"font/woff2":                        {text: "WOFF2 font", displayName: "WOFF2"},
+	"image/avif":                        {text: "AVIF image", displayName: "AVIF"},
+	"image/bmp":                         {text: "Windows BMP image", displayName: "BMP"},
+	"image/gif":                         {text: "GIF image", displayName: "GIF"},
+	"image/heic":                        {text: "HEIF image", displayName: "HEIC"},
+	"image/jpeg":                        {text: "JPEG image", displayName: "JPEG"},
+	"image/png":                         {text: "PNG image", displayName: "PNG"},
+	"image/svg+xml":                     {text: "SVG image", displayName: "SVG"},
+	"image/tiff":                        {text: "TIFF image", displayName: "TIFF"},
+	"image/vnd.adobe.photoshop":         {text: "Photoshop image", displayName: "PSD"},
+	"image/vnd.microsoft.icon":          {text: "Windows icon", displayName: "ICO"},
+	"image/webp":                        {text: "WebP image", displayName: "WebP"},
+	"image/x-icon":                      {text: "Windows icon", displayName: "ICO"},
+	"message/rfc822":                    {text: "Email message", displayName: "Email"},
+	"multipart/alternative":             {text: "Message in several formats", displayName: "Alternative"},
+	"multipart/form-data":               {text: "Form data with files", displayName: "Form data"},
+	"multipart/mixed":                   {text: "Compound message", displayName: "Mixed"},
+	"multipart/related":                 {text: "Compound document", displayName: "Related"},
+	"text/calendar":                     {text: "VCS/ICS calendar", displayName: "iCalendar"},
+	"text/css":                          {text: "CSS stylesheet", displayName: "CSS"},
+	"text/csv":                          {text: "CSV document", displayName: "CSV"},
+	"text/html":                         {text: "HTML document", displayName: "HTML"},
+	"text/javascript":                   {text: "JavaScript program", displayName: "JavaScript"},
+	"text/markdown":                     {text: "Markdown document", displayName: "Markdown"},
+	"text/plain":                        {text: "Plain text document", displayName: "Text"},
+	"text/tab-separated-values":         {text: "TSV document", displayName: "TSV"},
+	"text/vcard":                        {text: "Electronic business card", displayName: "vCard"},
+	"text/x-c":                          {text: "C source code", displayName: "C"},
+	"text/x-java-source":                {text: "Java source code", displayName: "Java"},
+	"text/x-markdown":                   {text: "Markdown document", displayName: "Markdown"},
+	"text/x-perl":                       {text: "Perl script", displayName: "Perl"},
+	"text/x-python":                     {text: "Python script", displayName: "Python"},
+	"text/x-yaml":                       {text: "YAML document", displayName: "YAML"},
+	"text/xml":                          {text: "XML document", displayName: "XML"},
+	"video/mp4":                         {text: "MPEG-4 video", displayName: "MP4"},
+	"video/mpeg":                        {text: "MPEG video", displayName: "MPEG"},
+	"video/ogg":                         {text: "Ogg video", displayName: "Ogg"},
+	"video/quicktime":                   {text: "QuickTime video", displayName: "QuickTime"},
+	"video/webm":                        {text: "WebM video", displayName: "WebM"},
+	"video/x-matroska":                  {text: "Matroska video", displayName: "Matroska"},
+	"video/x-msvideo":                   {text: "AVI video", displayName: "AVI"},
+}
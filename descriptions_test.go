@@ -0,0 +1,63 @@
+package mediatypes
+
+import (
+	"testing"
+)
+
+func TestMediaType_Description(t *testing.T) {
+	tests := []struct {
+		name            string
+		mediaType       string
+		wantDescription string
+		wantDisplayName string
+	}{
+		{
+			name:            "known",
+			mediaType:       "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			wantDescription: "Microsoft Excel spreadsheet (OOXML)",
+			wantDisplayName: "Excel",
+		},
+		{
+			name:            "derived acronym",
+			mediaType:       "image/x-pict",
+			wantDescription: "PICT image",
+			wantDisplayName: "PICT",
+		},
+		{
+			name:            "derived vendor tree",
+			mediaType:       "application/vnd.oasis.opendocument.chart",
+			wantDescription: "Oasis Opendocument Chart file",
+			wantDisplayName: "Oasis Opendocument Chart",
+		},
+		{
+			name:            "derived suffix",
+			mediaType:       "application/3gppHal+json",
+			wantDescription: "3gppHal (JSON) file",
+			wantDisplayName: "3gppHal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				m, ok := ByName(tt.mediaType)
+				if !ok {
+					t.Fatalf("ByName(%q) not found", tt.mediaType)
+				}
+				if got := m.Description(); got != tt.wantDescription {
+					t.Errorf("Description() = %q, want %q", got, tt.wantDescription)
+				}
+				if got := m.DisplayName(); got != tt.wantDisplayName {
+					t.Errorf("DisplayName() = %q, want %q", got, tt.wantDisplayName)
+				}
+			},
+		)
+	}
+}
+
+func TestDescriptions_Registered(t *testing.T) {
+	for name := range descriptions {
+		if _, ok := ByName(name); !ok {
+			t.Errorf("description for unknown media type %s", name)
+		}
+	}
+}
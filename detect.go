@@ -0,0 +1,88 @@
+package mediatypes
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is the number of bytes Detect considers.
+const sniffLen = 512
+
+// Detect returns the media type of the given content. It considers at most
+// the first 512 bytes, recognizes the signatures known to
+// http.DetectContentType and then refines container, XML, JSON and other text
+// formats, so a Word document is reported as
+// "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+// rather than "application/zip", and an SVG image as "image/svg+xml" rather
+// than "text/xml". Text is recognized in UTF-8, UTF-16, UTF-32 and the
+// common 8-bit encodings, and textual media types carry the detected charset
+// parameter. Signatures added with AddSignatures take part according to
+// their priority. If the content is not recognized, Detect returns
+// "application/octet-stream".
+func Detect(data []byte) MediaType {
+	if len(data) > sniffLen {
+		return detect(data[:sniffLen], false)
+	}
+	return detect(data, true)
+}
+
+// detect returns the media type of the given content, considering all of it.
+// If complete is set, data holds the whole content rather than a prefix.
+func detect(data []byte, complete bool) MediaType {
+	if m, ok := matchCustomSignatures(data, DefaultPriority, 100); ok {
+		return m
+	}
+	charset := DetectCharset(data)
+	text := decodeText(data, charset)
+
+	// Signatures are matched against the content as it is. Only if it is not
+	// recognized, and is text in a wide encoding, is the decoded text sniffed
+	// too, and then only for textual media types, such as HTML.
+	m := lookup(http.DetectContentType(data))
+	if isWideCharset(charset.Name) {
+		switch strings.ToLower(m.name) {
+		case "application/octet-stream", "text/plain":
+			if t := lookup(http.DetectContentType(text)); strings.HasPrefix(strings.ToLower(t.name), "text/") {
+				m = t
+			}
+		}
+	}
+	switch strings.ToLower(m.name) {
+	case "application/zip":
+		if c, ok := detectZipPrefix(data); ok {
+			m = c
+		}
+	case "text/xml", "text/plain":
+		if x, ok := DetectXML(bytes.NewReader(text)); ok {
+			m = x
+		} else if j, ok := DetectJSON(bytes.NewReader(text)); ok {
+			m = j
+		} else if c, ok := classifyTextBytes(text, complete); ok {
+			m = c
+		}
+	}
+	switch strings.ToLower(m.name) {
+	case "application/octet-stream", "text/plain":
+		if c, ok := matchCustomSignatures(data, 0, DefaultPriority-1); ok {
+			m = c
+		}
+	}
+	if charset.Name != "" && isTextual(m) {
+		m = m.WithParam("charset", charset.Name)
+	}
+	return m
+}
+
+// lookup returns the media type with the given name, ignoring any
+// parameters. If the registry does not contain it, lookup returns a media
+// type with just the name.
+func lookup(name string) MediaType {
+	if m, ok := ByName(name); ok {
+		return m
+	}
+	if i := strings.IndexByte(name, ';'); i >= 0 {
+		name = name[:i]
+	}
+	return MediaType{name: strings.ToLower(strings.TrimSpace(name))}
+}
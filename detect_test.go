@@ -0,0 +1,43 @@
+package mediatypes
+
+import (
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "empty", data: "", want: "text/plain"},
+		{name: "gif", data: "GIF89a\x01\x00\x01\x00", want: "image/gif"},
+		{name: "pdf", data: "%PDF-1.7\n", want: "application/pdf"},
+		{name: "html", data: "<!DOCTYPE html><html></html>", want: "text/html"},
+		{name: "binary", data: "\x00\x01\x02\x03", want: "application/octet-stream"},
+		{name: "svg", data: `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"/>`, want: "image/svg+xml"},
+		{name: "svg without prolog", data: `<svg xmlns="http://www.w3.org/2000/svg"/>`, want: "image/svg+xml"},
+		{name: "unknown xml", data: `<?xml version="1.0"?><config/>`, want: "text/xml"},
+		{name: "geojson", data: `{"type": "Point", "coordinates": [1, 2]}`, want: "application/geo+json"},
+		{name: "json", data: `{"a": 1}`, want: "application/json"},
+		{name: "python", data: "#!/usr/bin/env python\nprint('hi')\n", want: "text/x-python"},
+		{name: "tsv", data: "a\tb\n1\t2\n3\t4\n", want: "text/tab-separated-values"},
+		{name: "zip signature in utf-16", data: "P\x00K\x00\x03\x00\x04\x00", want: "application/octet-stream"},
+		{name: "bmp signature in utf-16", data: "B\x00M\x00", want: "application/octet-stream"},
+		{name: "utf-16 html", data: "\xff\xfe<\x00h\x00t\x00m\x00l\x00>\x00", want: "text/html"},
+		{name: "csv without final line break", data: "a,b\n1,2\n3,4", want: "text/csv"},
+		{name: "c source", data: "int a = 1;\nint b = 2;\nint c = 3;\nreturn a;\n", want: "text/plain"},
+		{name: "javascript source", data: "var x = 1;\nvar y = 2;\nconsole.log(x, y);\n", want: "text/plain"},
+		{name: "prose with commas", data: "Hello, my name is Bob.\nI live here, and I like it.\n", want: "text/plain"},
+		{name: "unregistered name", data: "RIFF\x00\x00\x00\x00WAVEfmt ", want: "audio/wave"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if got := Detect([]byte(tt.data)); got.Name() != tt.want {
+					t.Errorf("Detect() = %s, want %s", got.Name(), tt.want)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,64 @@
+package mediatypes
+
+import (
+	"sort"
+	"strings"
+)
+
+// Equal returns true if the media types have the same name and parameters.
+// As described by RFC 9110, names and parameter names are compared
+// case-insensitively, and so are charset values, while other parameter values
+// are compared exactly. So "Text/HTML;Charset=UTF-8" equals
+// "text/html; charset=utf-8".
+func (m MediaType) Equal(other MediaType) bool {
+	if !m.EqualIgnoringParams(other) || len(m.params) != len(other.params) {
+		return false
+	}
+	for k, v := range m.params {
+		w, ok := other.params[k]
+		if !ok || !equalParamValues(k, v, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// EqualIgnoringParams returns true if the media types have the same name,
+// compared case-insensitively, regardless of their parameters.
+func (m MediaType) EqualIgnoringParams(other MediaType) bool {
+	return strings.EqualFold(m.name, other.name)
+}
+
+// Key returns a canonical form of the media type, for use as a cache key.
+// Media types have the same key if and only if they are Equal. The key is
+// the lower-cased name followed by the parameters, ordered by name, with
+// charset values lower-cased.
+func (m MediaType) Key() string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(m.name))
+	names := make([]string, 0, len(m.params))
+	for name := range m.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := m.params[name]
+		if name == "charset" {
+			value = strings.ToLower(value)
+		}
+		b.WriteString("; ")
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(quoteString(value))
+	}
+	return b.String()
+}
+
+// equalParamValues returns true if the values of the named parameter are
+// equal.
+func equalParamValues(name, a, b string) bool {
+	if name == "charset" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
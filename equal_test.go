@@ -0,0 +1,60 @@
+package mediatypes
+
+import (
+	"testing"
+)
+
+func TestMediaType_Equal(t *testing.T) {
+	tests := []struct {
+		name             string
+		a, b             string
+		want             bool
+		wantIgnoreParams bool
+	}{
+		{name: "identical", a: "text/html", b: "text/html", want: true, wantIgnoreParams: true},
+		{name: "case", a: "Text/HTML;Charset=UTF-8", b: "text/html; charset=utf-8", want: true, wantIgnoreParams: true},
+		{name: "parameter order", a: "text/plain; format=flowed; charset=utf-8", b: "text/plain; charset=utf-8; format=flowed", want: true, wantIgnoreParams: true},
+		{name: "quoted value", a: `multipart/mixed; boundary="abc"`, b: "multipart/mixed; boundary=abc", want: true, wantIgnoreParams: true},
+		{name: "case-sensitive value", a: "multipart/mixed; boundary=abc", b: "multipart/mixed; boundary=ABC", want: false, wantIgnoreParams: true},
+		{name: "missing parameter", a: "text/html; charset=utf-8", b: "text/html", want: false, wantIgnoreParams: true},
+		{name: "different charset", a: "text/html; charset=utf-8", b: "text/html; charset=iso-8859-1", want: false, wantIgnoreParams: true},
+		{name: "different name", a: "text/html", b: "text/plain", want: false, wantIgnoreParams: false},
+		{name: "unregistered", a: "Application/X-Acme", b: "application/x-acme", want: true, wantIgnoreParams: true},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				a, err := Parse(tt.a)
+				if err != nil {
+					t.Fatalf("Parse(%q) error = %v", tt.a, err)
+				}
+				b, err := Parse(tt.b)
+				if err != nil {
+					t.Fatalf("Parse(%q) error = %v", tt.b, err)
+				}
+				if got := a.Equal(b); got != tt.want {
+					t.Errorf("Equal() = %v, want %v", got, tt.want)
+				}
+				if got := b.Equal(a); got != tt.want {
+					t.Errorf("Equal() is not symmetric: %v, want %v", got, tt.want)
+				}
+				if got := a.EqualIgnoringParams(b); got != tt.wantIgnoreParams {
+					t.Errorf("EqualIgnoringParams() = %v, want %v", got, tt.wantIgnoreParams)
+				}
+				if got := a.Key() == b.Key(); got != tt.want {
+					t.Errorf("Key() = %q and %q, equal = %v, want %v", a.Key(), b.Key(), got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestMediaType_Key(t *testing.T) {
+	m, err := Parse(`Text/Plain; Format=flowed; CHARSET=UTF-8; title="a b"`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got, want := m.Key(), `text/plain; charset="utf-8"; format="flowed"; title="a b"`; got != want {
+		t.Errorf("Key() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package mediatypes
+
+import (
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFilenameLen is the longest file name, in bytes, that common file
+// systems accept.
+const maxFilenameLen = 255
+
+// defaultFilename is the name FilenameFor uses if the base name is empty.
+const defaultFilename = "download"
+
+// preferredExtensions overrides the first registered extension of media
+// types whose first extension is not the one in common use.
+var preferredExtensions = map[string]string{
+	"audio/mpeg": "mp3",
+	"image/jpeg": "jpg",
+	"video/mpeg": "mpg",
+}
+
+// reservedFilenames holds the device names that Windows reserves, with or
+// without an extension.
+var reservedFilenames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true,
+	"com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true,
+	"lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// FilenameFor returns a file name for content of the given media type, for
+// use as a download name. It keeps base if it already has an extension of the
+// media type, and otherwise appends the preferred extension, so "report"
+// becomes "report.pdf" for "application/pdf". The name is made safe on
+// Windows and macOS: directories are removed, reserved characters and
+// controls are replaced by underscores, trailing dots and spaces are removed,
+// reserved device names such as "CON" are prefixed with an underscore, and
+// the name is shortened to 255 bytes. If base is empty, the name is
+// "download" with the extension.
+func FilenameFor(base string, mt MediaType) string {
+	name := sanitizeFilename(baseName(base))
+	if name == "" {
+		name = defaultFilename
+	}
+
+	ext := preferredExtension(mt)
+	if current := strings.TrimPrefix(path.Ext(name), "."); current != "" {
+		for _, e := range mt.extensions {
+			if strings.EqualFold(e, current) {
+				ext = ""
+				break
+			}
+		}
+	}
+	if ext != "" {
+		ext = "." + ext
+	}
+
+	if len(name)+len(ext) > maxFilenameLen {
+		name = truncateUTF8(name, maxFilenameLen-len(ext))
+		name = strings.TrimRight(name, ". ")
+	}
+	return name + ext
+}
+
+// AttachmentDisposition returns the value of a Content-Disposition header
+// that offers the content as a download with the given file name:
+//
+//	attachment; filename="M_rz.pdf"; filename*=UTF-8''M%C3%A4rz.pdf
+//
+// The filename parameter holds an ASCII form of the name for old clients, and the
+// filename* parameter holds the name encoded as described by RFC 8187, which
+// clients that support it prefer. Any directory is removed from the name.
+func AttachmentDisposition(filename string) string {
+	return formatDisposition(DispositionAttachment, baseName(filename), true)
+}
+
+// preferredExtension returns the extension commonly used for the media
+// type, without a dot, or an empty string if it has none.
+func preferredExtension(mt MediaType) string {
+	if ext, ok := preferredExtensions[strings.ToLower(mt.name)]; ok {
+		return ext
+	}
+	if len(mt.extensions) > 0 {
+		return mt.extensions[0]
+	}
+	return ""
+}
+
+// sanitizeFilename replaces the characters that Windows and macOS reserve
+// and removes what they do not allow at the end of a name.
+func sanitizeFilename(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r < 0x20 || r == 0x7f || r == utf8.RuneError:
+			b.WriteByte('_')
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	name = strings.TrimRight(b.String(), ". ")
+
+	stem := name
+	if i := strings.IndexByte(stem, '.'); i >= 0 {
+		stem = stem[:i]
+	}
+	if reservedFilenames[strings.ToLower(strings.TrimRight(stem, " "))] {
+		name = "_" + name
+	}
+	return name
+}
+
+// truncateUTF8 returns at most n bytes of s without splitting a character.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
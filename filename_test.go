@@ -0,0 +1,77 @@
+package mediatypes
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFilenameFor(t *testing.T) {
+	pdf, _ := ByName("application/pdf")
+	csv, _ := ByName("text/csv")
+	jpeg, _ := ByName("image/jpeg")
+	none := MediaType{name: "application/x-acme"}
+
+	tests := []struct {
+		name string
+		base string
+		mt   MediaType
+		want string
+	}{
+		{name: "appended", base: "report", mt: pdf, want: "report.pdf"},
+		{name: "kept", base: "export.CSV", mt: csv, want: "export.CSV"},
+		{name: "other extension", base: "export.2024", mt: csv, want: "export.2024.csv"},
+		{name: "preferred", base: "photo", mt: jpeg, want: "photo.jpg"},
+		{name: "alternative kept", base: "photo.jpeg", mt: jpeg, want: "photo.jpeg"},
+		{name: "no extensions", base: "data", mt: none, want: "data"},
+		{name: "empty", base: "", mt: pdf, want: "download.pdf"},
+		{name: "directory", base: `C:\Users\a/../b/report`, mt: pdf, want: "report.pdf"},
+		{name: "reserved characters", base: `a<b>c:d"e|f?g*h`, mt: pdf, want: "a_b_c_d_e_f_g_h.pdf"},
+		{name: "controls", base: "a\x00b\tc", mt: pdf, want: "a_b_c.pdf"},
+		{name: "trailing dots and spaces", base: "report. .", mt: pdf, want: "report.pdf"},
+		{name: "reserved name", base: "CON", mt: pdf, want: "_CON.pdf"},
+		{name: "reserved name with extension", base: "lpt1.tar", mt: pdf, want: "_lpt1.tar.pdf"},
+		{name: "not reserved", base: "console", mt: pdf, want: "console.pdf"},
+		{name: "unicode", base: "Bericht für März", mt: pdf, want: "Bericht für März.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if got := FilenameFor(tt.base, tt.mt); got != tt.want {
+					t.Errorf("FilenameFor(%q, %s) = %q, want %q", tt.base, tt.mt.Name(), got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestFilenameFor_Long(t *testing.T) {
+	pdf, _ := ByName("application/pdf")
+	got := FilenameFor(strings.Repeat("ä", 200), pdf)
+	if len(got) > maxFilenameLen || !utf8.ValidString(got) || !strings.HasSuffix(got, ".pdf") {
+		t.Errorf("FilenameFor() = %q (%d bytes), want a valid name of at most %d bytes ending in .pdf", got, len(got), maxFilenameLen)
+	}
+}
+
+func TestAttachmentDisposition(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     string
+	}{
+		{name: "ASCII", filename: "report.pdf", want: `attachment; filename="report.pdf"; filename*=UTF-8''report.pdf`},
+		{name: "non-ASCII", filename: "März.csv", want: `attachment; filename="M_rz.csv"; filename*=UTF-8''M%C3%A4rz.csv`},
+		{name: "space", filename: "Q1 report.pdf", want: `attachment; filename="Q1 report.pdf"; filename*=UTF-8''Q1%20report.pdf`},
+		{name: "directory", filename: "../etc/passwd", want: `attachment; filename="passwd"; filename*=UTF-8''passwd`},
+		{name: "empty", filename: "", want: "attachment"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if got := AttachmentDisposition(tt.filename); got != tt.want {
+					t.Errorf("AttachmentDisposition(%q) = %q, want %q", tt.filename, got, tt.want)
+				}
+			},
+		)
+	}
+}
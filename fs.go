@@ -0,0 +1,421 @@
+package mediatypes
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// fsOptions holds the options of FS.
+type fsOptions struct {
+	sniff bool
+}
+
+// FSOption configures FS.
+type FSOption func(*fsOptions)
+
+// WithSniffing makes the media type of a file depend on its content as well
+// as its name: textual media types carry the detected charset, and files
+// whose extension is unknown get the media type of their content, if it is
+// one that browsers do not execute; HTML, SVG, XML and other such content is
+// served as plain text. The
+// content is sniffed on the first read, or when the media type is first
+// asked for, from the first 512 bytes; reads are not affected.
+func WithSniffing() FSOption {
+	return func(o *fsOptions) {
+		o.sniff = true
+	}
+}
+
+// FileSystem is a file system whose files carry their media types. It is
+// returned by FS.
+type FileSystem struct {
+	fsys  fs.FS
+	sniff bool
+}
+
+// FS returns a file system that wraps fsys, such as an embed.FS or the result
+// of os.DirFS, and attaches a media type from this package's registry to each
+// file. The media type comes from the extension of the file name, or is
+// "application/octet-stream" if the extension is unknown, unless sniffing is
+// enabled with WithSniffing. The files it opens are of type *File, and their
+// Stat method returns a *FileInfo. Directories have the media type "inode/directory".
+func FS(fsys fs.FS, opts ...FSOption) *FileSystem {
+	var o fsOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &FileSystem{fsys: fsys, sniff: o.sniff}
+}
+
+// Open opens the named file. The result is a *File.
+func (s *FileSystem) Open(name string) (fs.File, error) {
+	return s.open(name)
+}
+
+// MediaType returns the media type of the named file.
+func (s *FileSystem) MediaType(name string) (MediaType, error) {
+	f, err := s.open(name)
+	if err != nil {
+		return MediaType{}, err
+	}
+	defer f.Close()
+	return f.mediaTypeOrError()
+}
+
+// open opens the named file.
+func (s *FileSystem) open(name string) (*File, error) {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &File{file: f, name: name, sniff: s.sniff}, nil
+}
+
+// File is a file opened from a FileSystem. Like other files, it is not safe
+// for concurrent use.
+type File struct {
+	file  fs.File
+	name  string
+	sniff bool
+
+	// resolved is set once mediaType holds the media type of the file.
+	resolved  bool
+	mediaType MediaType
+
+	// pending holds the sniffed bytes that were not yet returned by Read, if
+	// the file cannot seek.
+	pending []byte
+}
+
+// MediaType returns the media type of the file, or "application/octet-stream"
+// if it cannot be determined.
+func (f *File) MediaType() MediaType {
+	m, err := f.mediaTypeOrError()
+	if err != nil {
+		return lookup("application/octet-stream")
+	}
+	return m
+}
+
+// mediaTypeOrError returns the media type of the file.
+func (f *File) mediaTypeOrError() (MediaType, error) {
+	if f.resolved {
+		return f.mediaType, nil
+	}
+	info, err := f.file.Stat()
+	if err != nil {
+		return MediaType{}, err
+	}
+	if info.IsDir() {
+		f.mediaType, f.resolved = lookup("inode/directory"), true
+		return f.mediaType, nil
+	}
+
+	var head []byte
+	if f.sniff {
+		if head, err = f.sniffHead(); err != nil {
+			return MediaType{}, err
+		}
+	}
+	f.mediaType, f.resolved = fileMediaType(path.Base(f.name), head), true
+	return f.mediaType, nil
+}
+
+// fileMediaType returns the media type of a file with the given name and, if
+// not nil, the given first bytes. A known extension is authoritative, and the
+// content only contributes its charset. The content decides only if the
+// extension is unknown, and even then only if it is of a media type that
+// browsers do not execute; other text is plain text, and other binary data
+// is "application/octet-stream".
+func fileMediaType(filename string, head []byte) MediaType {
+	var content MediaType
+	if head != nil {
+		content = Detect(head)
+	}
+	m, ok := extensionMediaType(filename)
+	if !ok {
+		switch {
+		case head == nil:
+			return lookup("application/octet-stream")
+		case isSafeSniffedMediaType(content):
+			return content
+		case content.Param("charset") != "":
+			m = lookup("text/plain")
+		default:
+			return lookup("application/octet-stream")
+		}
+	}
+	if charset := content.Param("charset"); charset != "" && isTextual(m) {
+		m = m.WithCharset(charset)
+	}
+	return m
+}
+
+// safeSniffedMediaTypes holds the names of the media types that a file may
+// get from its content alone. Browsers display or download them, but do not
+// run scripts in them, as they may in HTML, SVG or any XML document.
+var safeSniffedMediaTypes = map[string]bool{
+	"application/gzip":          true,
+	"application/json":          true,
+	"application/ogg":           true,
+	"application/pdf":           true,
+	"application/zip":           true,
+	"audio/mpeg":                true,
+	"audio/ogg":                 true,
+	"audio/wave":                true,
+	"font/otf":                  true,
+	"font/ttf":                  true,
+	"font/woff":                 true,
+	"font/woff2":                true,
+	"image/bmp":                 true,
+	"image/gif":                 true,
+	"image/jpeg":                true,
+	"image/png":                 true,
+	"image/webp":                true,
+	"text/csv":                  true,
+	"text/plain":                true,
+	"text/tab-separated-values": true,
+	"video/mp4":                 true,
+	"video/webm":                true,
+}
+
+// isSafeSniffedMediaType returns true if a file may get the media type m
+// from its content alone: if it is in safeSniffedMediaTypes or is a ZIP-based
+// document format, such as a Word document.
+func isSafeSniffedMediaType(m MediaType) bool {
+	name := strings.ToLower(m.name)
+	return safeSniffedMediaTypes[name] || isZipContainer(name)
+}
+
+// preferredMediaTypes holds the media type to use for extensions that are
+// associated with several media types, or with none in the registry.
+var preferredMediaTypes = map[string]string{
+	"css":   "text/css",
+	"csv":   "text/csv",
+	"exe":   "application/vnd.microsoft.portable-executable",
+	"gz":    "application/gzip",
+	"jar":   "application/java-archive",
+	"jpeg":  "image/jpeg",
+	"jpg":   "image/jpeg",
+	"js":    "text/javascript",
+	"mjs":   "text/javascript",
+	"mp3":   "audio/mpeg",
+	"mp4":   "video/mp4",
+	"ogg":   "audio/ogg",
+	"otf":   "font/otf",
+	"ppt":   "application/vnd.ms-powerpoint",
+	"rtf":   "application/rtf",
+	"wasm":  "application/wasm",
+	"wav":   "audio/wav",
+	"woff":  "font/woff",
+	"woff2": "font/woff2",
+	"xls":   "application/vnd.ms-excel",
+	"xml":   "application/xml",
+	"zip":   "application/zip",
+}
+
+// extensionMediaType returns the media type for the extension of the file
+// name. If the extension is associated with several media types, it prefers
+// registered ones.
+func extensionMediaType(filename string) (MediaType, bool) {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(filename), "."))
+	if ext == "" {
+		return MediaType{}, false
+	}
+	if name, ok := preferredMediaTypes[ext]; ok {
+		return lookup(name), true
+	}
+	var result MediaType
+	found := false
+	for _, m := range ByExtension(ext) {
+		if strings.EqualFold(m.name, "application/octet-stream") {
+			continue
+		}
+		if !found || (m.registered && !result.registered) {
+			result, found = m, true
+		}
+	}
+	return result, found
+}
+
+// sniffHead returns the first bytes of the file. If the file can seek, it
+// reads them from the start and restores the offset; otherwise it reads them
+// at the current offset and keeps them for the next reads.
+func (f *File) sniffHead() ([]byte, error) {
+	head := make([]byte, sniffLen)
+	if seeker, ok := f.file.(io.Seeker); ok {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		n, err := io.ReadFull(f.file, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return head[:n], nil
+	}
+	n, err := io.ReadFull(f.file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	f.pending = head[:n]
+	return f.pending, nil
+}
+
+// Stat returns information about the file. The result is a *FileInfo.
+func (f *File) Stat() (fs.FileInfo, error) {
+	info, err := f.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	m, err := f.mediaTypeOrError()
+	if err != nil {
+		return nil, err
+	}
+	return &FileInfo{FileInfo: info, mediaType: m}, nil
+}
+
+// Read reads from the file. If the file is sniffed, the first read sniffs
+// it, and the content is returned unchanged.
+func (f *File) Read(p []byte) (int, error) {
+	if f.sniff && !f.resolved {
+		if _, err := f.mediaTypeOrError(); err != nil {
+			return 0, err
+		}
+	}
+	if len(f.pending) > 0 {
+		n := copy(p, f.pending)
+		f.pending = f.pending[n:]
+		return n, nil
+	}
+	return f.file.Read(p)
+}
+
+// Seek sets the offset for the next read, if the underlying file can seek.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := f.file.(io.Seeker)
+	if !ok {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: errors.New("mediatypes: file cannot seek")}
+	}
+	return seeker.Seek(offset, whence)
+}
+
+// ReadDir reads the entries of a directory, if the underlying file is one.
+func (f *File) ReadDir(n int) ([]fs.DirEntry, error) {
+	dir, ok := f.file.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("mediatypes: not a directory")}
+	}
+	return dir.ReadDir(n)
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	return f.file.Close()
+}
+
+// FileInfo describes a file of a FileSystem, including its media type.
+type FileInfo struct {
+	fs.FileInfo
+	mediaType MediaType
+}
+
+// MediaType returns the media type of the file.
+func (i *FileInfo) MediaType() MediaType {
+	return i.mediaType
+}
+
+// FileServer returns a handler that serves the files of fsys, as
+// http.FileServer does, but sets the Content-Type header from this package's
+// registry instead of the tables of the mime package. The content is always
+// sniffed, as with WithSniffing, so that textual media types carry their
+// charset, and the X-Content-Type-Options header is set to "nosniff", so that
+// browsers use the media type as given.
+func FileServer(fsys fs.FS, opts ...FSOption) http.Handler {
+	s := FS(fsys, append(opts, WithSniffing())...)
+	return &fileServer{fs: s, handler: http.FileServer(http.FS(s))}
+}
+
+// fileServer serves the files of a FileSystem. It serves regular files, and
+// the index.html of directories, itself, and leaves redirects, directory
+// listings and errors to handler.
+type fileServer struct {
+	fs      *FileSystem
+	handler http.Handler
+}
+
+func (h *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upath := r.URL.Path
+	if !strings.HasPrefix(upath, "/") {
+		upath = "/" + upath
+	}
+	// http.FileServer redirects requests for index.html to the directory.
+	if !strings.HasSuffix(upath, "/index.html") {
+		if f, info, ok := h.open(upath); ok {
+			defer f.Close()
+			w.Header().Set("Content-Type", info.MediaType().ContentType())
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+			return
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+// open opens the regular file to serve for the URL path: the named file, or
+// the index.html of the named directory. It returns false if there is none,
+// or if http.FileServer would redirect the request.
+func (h *fileServer) open(upath string) (*File, *FileInfo, bool) {
+	name := strings.TrimPrefix(path.Clean(upath), "/")
+	if name == "" {
+		name = "."
+	}
+	dirPath := strings.HasSuffix(upath, "/")
+	f, info, err := h.stat(name)
+	if err != nil {
+		return nil, nil, false
+	}
+	if info.IsDir() {
+		f.Close()
+		if !dirPath {
+			return nil, nil, false
+		}
+		if f, info, err = h.stat(path.Join(name, "index.html")); err != nil {
+			return nil, nil, false
+		}
+		if info.IsDir() {
+			f.Close()
+			return nil, nil, false
+		}
+		return f, info, true
+	}
+	if dirPath {
+		f.Close()
+		return nil, nil, false
+	}
+	return f, info, true
+}
+
+// stat opens the named file and returns its information.
+func (h *fileServer) stat(name string) (*File, *FileInfo, error) {
+	f, err := h.fs.open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, info.(*FileInfo), nil
+}
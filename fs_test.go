@@ -0,0 +1,183 @@
+package mediatypes
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testFS returns a file system for the tests.
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":       {Data: []byte("<!DOCTYPE html><title>Home</title>")},
+		"docs/report.pdf":  {Data: []byte("%PDF-1.7\n")},
+		"docs/photo.jpg":   {Data: []byte("%PDF-1.7\n")},
+		"docs/notes.txt":   {Data: []byte("Caf\xc3\xa9 au lait\n")},
+		"docs/chart":       {Data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")},
+		"static/app.js":    {Data: []byte("console.log(1);\n")},
+		"static/empty.css": {Data: []byte{}},
+		"static/legacy.js": {Data: []byte("<!-- hide from old browsers\nalert(1);\n")},
+		"xss/notes.txt":    {Data: []byte("<script>alert(document.cookie)</script>")},
+		"xss/data.json":    {Data: []byte("<html><body>{}</body></html>")},
+		"xss/a.csv":        {Data: []byte("<p>a,b\n")},
+		"xss/page":         {Data: []byte("<!DOCTYPE html><script>alert(1)</script>")},
+		"xss/feed":         {Data: []byte(`<?xml version="1.0"?><rss version="2.0"><channel/></rss>`)},
+		"xss/xml":          {Data: []byte(`<?xml version="1.0"?><root><x:script xmlns:x="http://www.w3.org/1999/xhtml">alert(1)</x:script></root>`)},
+		"xss/binary":       {Data: []byte("\x00\x01\x02\x03")},
+		"xss/image":        {Data: []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"><script>alert(1)</script></svg>")},
+	}
+}
+
+func TestFS(t *testing.T) {
+	if err := fstest.TestFS(FS(testFS()), "index.html", "docs/report.pdf", "docs/chart"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(FS(testFS(), WithSniffing()), "index.html", "docs/report.pdf", "docs/chart"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFS_MediaType(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		opts []FSOption
+		want string
+	}{
+		{name: "extension", file: "docs/report.pdf", want: "application/pdf"},
+		{name: "unknown extension", file: "docs/chart", want: "application/octet-stream"},
+		{name: "sniffed", file: "docs/chart", opts: []FSOption{WithSniffing()}, want: "image/png"},
+		{name: "sniffed charset", file: "docs/notes.txt", opts: []FSOption{WithSniffing()}, want: "text/plain; charset=utf-8"},
+		{name: "ambiguous extension", file: "static/app.js", want: "text/javascript"},
+		{name: "extension outweighs content", file: "docs/photo.jpg", opts: []FSOption{WithSniffing()}, want: "image/jpeg"},
+		{name: "html in text", file: "xss/notes.txt", opts: []FSOption{WithSniffing()}, want: "text/plain; charset=utf-8"},
+		{name: "html in script", file: "static/legacy.js", opts: []FSOption{WithSniffing()}, want: "text/javascript; charset=utf-8"},
+		{name: "html in json", file: "xss/data.json", opts: []FSOption{WithSniffing()}, want: "application/json"},
+		{name: "html in csv", file: "xss/a.csv", opts: []FSOption{WithSniffing()}, want: "text/csv; charset=utf-8"},
+		{name: "html without extension", file: "xss/page", opts: []FSOption{WithSniffing()}, want: "text/plain; charset=utf-8"},
+		{name: "xml without extension", file: "xss/xml", opts: []FSOption{WithSniffing()}, want: "text/plain; charset=utf-8"},
+		{name: "feed without extension", file: "xss/feed", opts: []FSOption{WithSniffing()}, want: "text/plain; charset=utf-8"},
+		{name: "binary without extension", file: "xss/binary", opts: []FSOption{WithSniffing()}, want: "application/octet-stream"},
+		{name: "svg without extension", file: "xss/image", opts: []FSOption{WithSniffing()}, want: "text/plain; charset=utf-8"},
+		{name: "generic content", file: "static/empty.css", opts: []FSOption{WithSniffing()}, want: "text/css; charset=utf-8"},
+		{name: "directory", file: "docs", want: "inode/directory"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				fsys := FS(testFS(), tt.opts...)
+				m, err := fsys.MediaType(tt.file)
+				if err != nil {
+					t.Fatalf("MediaType(%q) error = %v", tt.file, err)
+				}
+				if got := m.ContentType(); got != tt.want {
+					t.Errorf("MediaType(%q) = %q, want %q", tt.file, got, tt.want)
+				}
+
+				info, err := fs.Stat(fsys, tt.file)
+				if err != nil {
+					t.Fatalf("Stat(%q) error = %v", tt.file, err)
+				}
+				fi, ok := info.(*FileInfo)
+				if !ok {
+					t.Fatalf("Stat(%q) = %T, want *FileInfo", tt.file, info)
+				}
+				if got := fi.MediaType(); got.ContentType() != tt.want {
+					t.Errorf("Stat(%q).MediaType() = %q, want %q", tt.file, got.ContentType(), tt.want)
+				}
+			},
+		)
+	}
+	if _, err := FS(testFS()).MediaType("missing"); err == nil {
+		t.Error("MediaType(\"missing\") error = nil, want an error")
+	}
+}
+
+// streamFS opens files that cannot seek.
+type streamFS struct {
+	fs.FS
+}
+
+func (s streamFS) Open(name string) (fs.File, error) {
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{f}, nil
+}
+
+func TestFile_Read(t *testing.T) {
+	for _, fsys := range []fs.FS{testFS(), streamFS{testFS()}} {
+		f, err := FS(fsys, WithSniffing()).Open("docs/chart")
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		buf := make([]byte, 3)
+		if _, err := io.ReadFull(f, buf); err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		rest, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if got, want := string(buf)+string(rest), string(testFS()["docs/chart"].Data); got != want {
+			t.Errorf("%T: Read() = %q, want %q", fsys, got, want)
+		}
+		if got := f.(*File).MediaType(); got.Name() != "image/png" {
+			t.Errorf("%T: MediaType() = %q, want %q", fsys, got.Name(), "image/png")
+		}
+		f.Close()
+	}
+}
+
+func TestFileServer(t *testing.T) {
+	tests := []struct {
+		path            string
+		wantStatus      int
+		wantContentType string
+		wantNoSniff     bool
+	}{
+		{path: "/", wantStatus: http.StatusOK, wantContentType: "text/html; charset=utf-8", wantNoSniff: true},
+		{path: "/docs/report.pdf", wantStatus: http.StatusOK, wantContentType: "application/pdf", wantNoSniff: true},
+		{path: "/docs/notes.txt", wantStatus: http.StatusOK, wantContentType: "text/plain; charset=utf-8", wantNoSniff: true},
+		{path: "/docs/chart", wantStatus: http.StatusOK, wantContentType: "image/png", wantNoSniff: true},
+		{path: "/static/app.js", wantStatus: http.StatusOK, wantContentType: "text/javascript; charset=utf-8", wantNoSniff: true},
+		{path: "/xss/notes.txt", wantStatus: http.StatusOK, wantContentType: "text/plain; charset=utf-8", wantNoSniff: true},
+		{path: "/static/legacy.js", wantStatus: http.StatusOK, wantContentType: "text/javascript; charset=utf-8", wantNoSniff: true},
+		{path: "/xss/data.json", wantStatus: http.StatusOK, wantContentType: "application/json", wantNoSniff: true},
+		{path: "/xss/a.csv", wantStatus: http.StatusOK, wantContentType: "text/csv; charset=utf-8", wantNoSniff: true},
+		{path: "/xss/xml", wantStatus: http.StatusOK, wantContentType: "text/plain; charset=utf-8", wantNoSniff: true},
+		{path: "/docs", wantStatus: http.StatusMovedPermanently},
+		{path: "/docs/report.pdf/", wantStatus: http.StatusMovedPermanently},
+		{path: "/xss/page", wantStatus: http.StatusOK, wantContentType: "text/plain; charset=utf-8", wantNoSniff: true},
+		{path: "/docs/", wantStatus: http.StatusOK, wantContentType: "text/html; charset=utf-8"},
+		{path: "/index.html", wantStatus: http.StatusMovedPermanently},
+		{path: "/missing", wantStatus: http.StatusNotFound, wantContentType: "text/plain; charset=utf-8"},
+	}
+	handler := FileServer(testFS())
+	for _, tt := range tests {
+		t.Run(
+			tt.path, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+				}
+				if got := w.Header().Get("Content-Type"); tt.wantContentType != "" && got != tt.wantContentType {
+					t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+				}
+				if tt.wantNoSniff && w.Header().Get("X-Content-Type-Options") != "nosniff" {
+					t.Error("X-Content-Type-Options is not nosniff")
+				}
+				if tt.wantStatus == http.StatusOK && strings.TrimSpace(w.Body.String()) == "" && tt.path != "/static/empty.css" {
+					t.Error("body is empty")
+				}
+			},
+		)
+	}
+}
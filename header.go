@@ -0,0 +1,151 @@
+package mediatypes
+
+import (
+	"sort"
+	"strings"
+)
+
+// formatOptions holds the options of ContentType.
+type formatOptions struct {
+	lowerCase bool
+}
+
+// FormatOption configures ContentType.
+type FormatOption func(*formatOptions)
+
+// WithLowerCase renders the type and subtype in lower case. By default they
+// are rendered as registered, such as "application/3gppHal+json".
+func WithLowerCase() FormatOption {
+	return func(o *formatOptions) {
+		o.lowerCase = true
+	}
+}
+
+// ContentType returns the media type with its parameters as a value for the
+// Content-Type header. Parameters are ordered by name, so the result is
+// stable. Values that are not tokens are quoted as described by RFC 9110, and
+// values with non-ASCII characters are encoded as described by RFC 2231:
+//
+//	text/plain; charset=utf-8; format=flowed
+//	multipart/form-data; boundary="a:b"
+//	text/plain; title*=UTF-8''%E2%82%AC%20rates
+//
+// Parameters whose names are not tokens are omitted.
+func (m MediaType) ContentType(opts ...FormatOption) string {
+	var o formatOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var b strings.Builder
+	if o.lowerCase {
+		b.WriteString(strings.ToLower(m.name))
+	} else {
+		b.WriteString(m.name)
+	}
+	names := make([]string, 0, len(m.params))
+	for name := range m.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeParam(&b, name, m.params[name])
+	}
+	return b.String()
+}
+
+// writeParam writes "; name=value" to b, quoting or encoding the value as
+// required. It writes nothing if name is not a token.
+func writeParam(b *strings.Builder, name, value string) {
+	if !isToken(name) {
+		return
+	}
+	b.WriteString("; ")
+	b.WriteString(name)
+	switch {
+	case isToken(value):
+		b.WriteByte('=')
+		b.WriteString(value)
+	case needsExtendedValue(value):
+		b.WriteString("*=")
+		b.WriteString(encodeExtendedValue(value))
+	default:
+		b.WriteByte('=')
+		b.WriteString(quoteString(value))
+	}
+}
+
+// isToken returns true if s is a token as defined by RFC 9110: one or more
+// characters other than separators, spaces and controls.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isTokenChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTokenChar returns true if c is a tchar as defined by RFC 9110.
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+// needsExtendedValue returns true if s cannot be sent as a quoted-string,
+// because it has characters outside of ASCII or controls other than tab.
+func needsExtendedValue(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= 0x7f || (c < 0x20 && c != '\t') {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteString returns s as a quoted-string as defined by RFC 9110, escaping
+// quotes and backslashes.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// encodeExtendedValue returns s as an extended value as defined by RFC 2231
+// and RFC 8187: the charset, an empty language, and the percent-encoded
+// UTF-8 bytes.
+func encodeExtendedValue(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	b.WriteString("UTF-8''")
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAttrChar(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0xf])
+	}
+	return b.String()
+}
+
+// isAttrChar returns true if c may appear unencoded in an extended value, as
+// defined by RFC 8187.
+func isAttrChar(c byte) bool {
+	return isTokenChar(c) && c != '*' && c != '\'' && c != '%'
+}
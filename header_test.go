@@ -0,0 +1,61 @@
+package mediatypes
+
+import (
+	"mime"
+	"testing"
+)
+
+func TestMediaType_ContentType(t *testing.T) {
+	plain, _ := ByName("text/plain")
+	form, _ := ByName("multipart/form-data")
+	hal, _ := ByName("application/3gppHal+json")
+
+	tests := []struct {
+		name string
+		m    MediaType
+		opts []FormatOption
+		want string
+	}{
+		{name: "no parameters", m: plain, want: "text/plain"},
+		{name: "ordered", m: plain.WithParam("format", "flowed").WithCharset("utf-8"), want: "text/plain; charset=utf-8; format=flowed"},
+		{name: "replaced", m: plain.WithCharset("iso-8859-1").WithParam("Charset", "utf-8"), want: "text/plain; charset=utf-8"},
+		{name: "tspecials", m: form.WithParam("boundary", "a:b/c"), want: `multipart/form-data; boundary="a:b/c"`},
+		{name: "space", m: form.WithParam("boundary", "a b"), want: `multipart/form-data; boundary="a b"`},
+		{name: "escaped", m: plain.WithParam("title", `say "hi" \o/`), want: `text/plain; title="say \"hi\" \\o/"`},
+		{name: "empty value", m: plain.WithParam("title", ""), want: `text/plain; title=""`},
+		{name: "non-ASCII", m: plain.WithParam("title", "€ rates"), want: "text/plain; title*=UTF-8''%E2%82%AC%20rates"},
+		{name: "control", m: plain.WithParam("title", "a\nb"), want: "text/plain; title*=UTF-8''a%0Ab"},
+		{name: "invalid name", m: plain.WithParam("a b", "c"), want: "text/plain"},
+		{name: "registered case", m: hal, want: "application/3gppHal+json"},
+		{name: "lower case", m: hal, opts: []FormatOption{WithLowerCase()}, want: "application/3gpphal+json"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got := tt.m.ContentType(tt.opts...)
+				if got != tt.want {
+					t.Errorf("ContentType() = %q, want %q", got, tt.want)
+				}
+				_, params, err := mime.ParseMediaType(got)
+				if err != nil {
+					t.Fatalf("mime.ParseMediaType(%q) error = %v", got, err)
+				}
+				for k, v := range tt.m.Params() {
+					if isToken(k) && params[k] != v {
+						t.Errorf("mime.ParseMediaType(%q) parameter %s = %q, want %q", got, k, params[k], v)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestMediaType_ContentType_Chain(t *testing.T) {
+	m, _ := ByName("text/plain")
+	if got, want := m.WithCharset("utf-8").ContentType(WithLowerCase()), "text/plain; charset=utf-8"; got != want {
+		t.Errorf("ContentType() = %q, want %q", got, want)
+	}
+	if got := m.ContentType(); got != "text/plain" {
+		t.Errorf("WithCharset() modified the original: ContentType() = %q", got)
+	}
+}
@@ -0,0 +1,417 @@
+package mediatypes
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// dangerousMediaTypes holds the names of media types that can run code when
+// opened: executables, scripts, and documents that can run script in a
+// browser.
+var dangerousMediaTypes = map[string]bool{
+	"application/hta":                               true,
+	"application/java-archive":                      true,
+	"application/javascript":                        true,
+	"application/vnd.android.package-archive":       true,
+	"application/vnd.microsoft.portable-executable": true,
+	"application/x-executable":                      true,
+	"application/x-ms-shortcut":                     true,
+	"application/x-msdos-program":                   true,
+	"application/x-msdownload":                      true,
+	"application/x-sh":                              true,
+	"application/x-shellscript":                     true,
+	"application/x-xpinstall":                       true,
+	"application/xhtml+xml":                         true,
+	"image/svg+xml":                                 true,
+	"text/html":                                     true,
+	"text/javascript":                               true,
+	"text/x-sh":                                     true,
+}
+
+// isDangerous returns true if m is a media type that can run code when
+// opened, including Office documents with macros.
+func isDangerous(m MediaType) bool {
+	name := strings.ToLower(m.name)
+	return dangerousMediaTypes[name] || strings.Contains(name, ".macroenabled")
+}
+
+// inventoryOptions holds the options of Inventory.
+type inventoryOptions struct {
+	workers  int
+	progress func(Progress)
+	detect   []DetectOption
+}
+
+// InventoryOption configures Inventory.
+type InventoryOption func(*inventoryOptions)
+
+// WithWorkers sets the number of files classified concurrently. The default
+// is the number of CPUs.
+func WithWorkers(n int) InventoryOption {
+	return func(o *inventoryOptions) {
+		o.workers = n
+	}
+}
+
+// WithProgress sets a function that is called after each file is
+// classified. Calls are not concurrent.
+func WithProgress(fn func(Progress)) InventoryOption {
+	return func(o *inventoryOptions) {
+		o.progress = fn
+	}
+}
+
+// WithDetectOptions sets the options used to classify the content of each
+// file, as for DetectReaderAt.
+func WithDetectOptions(opts ...DetectOption) InventoryOption {
+	return func(o *inventoryOptions) {
+		o.detect = opts
+	}
+}
+
+// Progress reports the progress of Inventory.
+type Progress struct {
+	// Path is the file that was classified last.
+	Path string
+
+	// Files is the number of files classified so far.
+	Files int
+
+	// Bytes is the total size of the files classified so far.
+	Bytes int64
+}
+
+// FileReport describes a file found by Inventory.
+type FileReport struct {
+	// Path is the path of the file in the file system.
+	Path string `json:"path"`
+
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+
+	// MediaType is the media type of the file: the detected media type if
+	// the content identifies a specific format, and otherwise the media type
+	// for the extension.
+	MediaType MediaType `json:"mediaType"`
+
+	// Extension is the media type for the extension of the file name, or
+	// empty if the extension is unknown.
+	Extension MediaType `json:"extension"`
+
+	// Detected is the media type detected from the content.
+	Detected MediaType `json:"detected"`
+
+	// Mismatch is true if the content is not consistent with the extension:
+	// either it identifies a specific format of its own, or the extension
+	// names a binary format but the content is generic, such as plain text or
+	// unrecognized binary data.
+	Mismatch bool `json:"mismatch"`
+
+	// Unknown is true if neither the extension nor the content identifies
+	// the media type.
+	Unknown bool `json:"unknown"`
+
+	// Dangerous is true if the file can run code when opened, by its
+	// extension or its content.
+	Dangerous bool `json:"dangerous"`
+
+	// Error holds the error that prevented the file from being classified,
+	// if any.
+	Error string `json:"error,omitempty"`
+}
+
+// InventoryReport is the result of Inventory.
+type InventoryReport struct {
+	// Files describes each regular file, sorted by path.
+	Files []FileReport `json:"files"`
+
+	// MediaTypes counts the files by media type.
+	MediaTypes map[string]int `json:"mediaTypes"`
+
+	// TopLevelTypes counts the files by top-level type, such as "image".
+	TopLevelTypes map[string]int `json:"topLevelTypes"`
+
+	// Mismatches, Unknown, Dangerous and Errors count the files with each
+	// property.
+	Mismatches int `json:"mismatches"`
+	Unknown    int `json:"unknown"`
+	Dangerous  int `json:"dangerous"`
+	Errors     int `json:"errors"`
+}
+
+// Inventory walks fsys and classifies each regular file by its extension and
+// content, reporting counts per media type and top-level type, files whose
+// extension disagrees with their content, files of unknown type, and files
+// that can run code. Files are classified concurrently by a pool of workers,
+// each reading at most what DetectReaderAt reads. Files that cannot be read
+// or directories that cannot be listed are reported with an error rather than
+// failing the inventory; Inventory returns an error only if the root cannot
+// be read or the context is done.
+func Inventory(ctx context.Context, fsys fs.FS, opts ...InventoryOption) (*InventoryReport, error) {
+	o := inventoryOptions{workers: runtime.NumCPU()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.workers < 1 {
+		o.workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	paths := make(chan string)
+	results := make(chan FileReport)
+	walkErr := make(chan error, 1)
+	// unreadable holds the directories that could not be read. It is only
+	// read once the walk is done.
+	var unreadable []FileReport
+	go func() {
+		defer close(paths)
+		walkErr <- fs.WalkDir(
+			fsys, ".", func(name string, d fs.DirEntry, err error) error {
+				if err != nil {
+					if name == "." {
+						return err
+					}
+					unreadable = append(unreadable, FileReport{Path: name, Error: err.Error()})
+					if d != nil && d.IsDir() {
+						return fs.SkipDir
+					}
+					return nil
+				}
+				if !d.Type().IsRegular() {
+					return nil
+				}
+				select {
+				case paths <- name:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			},
+		)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(o.workers)
+	for i := 0; i < o.workers; i++ {
+		go func() {
+			defer wg.Done()
+			for name := range paths {
+				r := classifyFile(ctx, fsys, name, o.detect)
+				select {
+				case results <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	report := &InventoryReport{MediaTypes: make(map[string]int), TopLevelTypes: make(map[string]int)}
+	var progress Progress
+	for r := range results {
+		report.add(r)
+		progress.Path = r.Path
+		progress.Files++
+		progress.Bytes += r.Size
+		if o.progress != nil {
+			o.progress(progress)
+		}
+	}
+	if err := <-walkErr; err != nil {
+		return nil, err
+	}
+	for _, r := range unreadable {
+		report.add(r)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(
+		report.Files, func(i, j int) bool {
+			return report.Files[i].Path < report.Files[j].Path
+		},
+	)
+	return report, nil
+}
+
+// add adds a file to the report.
+func (r *InventoryReport) add(f FileReport) {
+	r.Files = append(r.Files, f)
+	if f.Error != "" {
+		r.Errors++
+		return
+	}
+	name := strings.ToLower(f.MediaType.name)
+	r.MediaTypes[name]++
+	if i := strings.IndexByte(name, '/'); i > 0 {
+		r.TopLevelTypes[name[:i]]++
+	}
+	if f.Mismatch {
+		r.Mismatches++
+	}
+	if f.Unknown {
+		r.Unknown++
+	}
+	if f.Dangerous {
+		r.Dangerous++
+	}
+}
+
+// classifyFile classifies the named file.
+func classifyFile(ctx context.Context, fsys fs.FS, name string, opts []DetectOption) FileReport {
+	r := FileReport{Path: name}
+	fail := func(err error) FileReport {
+		r.Error = err.Error()
+		return r
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fail(err)
+	}
+	r.Size = info.Size()
+
+	ra, ok := f.(io.ReaderAt)
+	size := r.Size
+	if !ok {
+		// Files that cannot read at an offset are classified from their
+		// start.
+		head := make([]byte, sniffLen)
+		n, err := io.ReadFull(f, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fail(err)
+		}
+		ra, size = readerAtBytes(head[:n]), int64(n)
+	}
+	result, err := DetectReaderAt(ctx, ra, size, opts...)
+	if err != nil {
+		return fail(err)
+	}
+	r.Detected = result.MediaType
+	if strings.EqualFold(r.Detected.name, "application/octet-stream") {
+		// Detect does not recognize executables, which Scan does.
+		head := make([]byte, sniffLen)
+		n, _ := ra.ReadAt(head, 0)
+		if m, ok := scanStart(head[:n]); ok {
+			r.Detected = m
+		}
+	}
+
+	ext, known := extensionMediaType(path.Base(name))
+	if known {
+		r.Extension = ext
+	}
+	specific := !genericMediaTypes[strings.ToLower(r.Detected.name)]
+	switch {
+	case specific:
+		r.MediaType = r.Detected
+	case known:
+		r.MediaType = ext
+		if charset := r.Detected.Param("charset"); charset != "" && isTextual(ext) {
+			r.MediaType = ext.WithCharset(charset)
+		}
+	default:
+		r.MediaType = r.Detected
+	}
+	binary := known && !isTextual(ext) && !genericMediaTypes[strings.ToLower(ext.name)]
+	switch {
+	case !known:
+	case strings.EqualFold(r.Detected.name, "application/octet-stream"):
+		// Every format specializes unrecognized content, so it is only
+		// consistent with extensions that do not promise a binary format.
+		r.Mismatch = binary
+	case specific || binary:
+		r.Mismatch = !consistentWithExtension(r.Detected, path.Base(name))
+	}
+	r.Unknown = !known && strings.EqualFold(r.Detected.name, "application/octet-stream")
+	r.Dangerous = isDangerous(r.Detected) || (known && isDangerous(ext))
+	return r
+}
+
+// consistentWithExtension returns true if m is consistent with any of the
+// media types associated with the extension of the file name, other than
+// "application/octet-stream", with which everything is consistent.
+func consistentWithExtension(m MediaType, filename string) bool {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(filename), "."))
+	candidates := ByExtension(ext)
+	if preferred, ok := preferredMediaTypes[ext]; ok {
+		candidates = append(candidates, lookup(preferred))
+	}
+	for _, c := range candidates {
+		if strings.EqualFold(c.name, "application/octet-stream") {
+			continue
+		}
+		if m.EqualIgnoringParams(c) || specializes(m, c) || specializes(c, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// readerAtBytes reads at an offset of a byte slice.
+type readerAtBytes []byte
+
+func (b readerAtBytes) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(b)) {
+		return 0, io.EOF
+	}
+	n := copy(p, b[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *InventoryReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the files of the report as CSV, with a header row.
+func (r *InventoryReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"path", "size", "media_type", "extension", "detected", "mismatch", "unknown", "dangerous", "error"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, f := range r.Files {
+		record := []string{
+			f.Path,
+			strconv.FormatInt(f.Size, 10),
+			f.MediaType.ContentType(),
+			f.Extension.ContentType(),
+			f.Detected.ContentType(),
+			strconv.FormatBool(f.Mismatch),
+			strconv.FormatBool(f.Unknown),
+			strconv.FormatBool(f.Dangerous),
+			f.Error,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
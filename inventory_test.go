@@ -0,0 +1,139 @@
+package mediatypes
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// inventoryFS returns a file system for the inventory tests.
+func inventoryFS(t *testing.T) fstest.MapFS {
+	return fstest.MapFS{
+		"docs/report.pdf":   {Data: []byte("%PDF-1.7\n")},
+		"docs/notes.txt":    {Data: []byte("Caf\xc3\xa9 au lait\n")},
+		"docs/letter.docx":  {Data: makeZip(t, contentTypes("application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"))},
+		"images/logo.png":   {Data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")},
+		"images/photo.jpg":  {Data: []byte("%PDF-1.7\n")},
+		"web/index.html":    {Data: []byte("<!DOCTYPE html><title>Home</title>")},
+		"web/app.js":        {Data: []byte("console.log(1);\n")},
+		"blobs/data":        {Data: []byte("\x00\x01\x02\x03")},
+		"blobs/setup.exe":   {Data: []byte("MZ" + strings.Repeat("\x00", 58) + "\x40\x00\x00\x00PE\x00\x00")},
+		"blobs/tool.exe":    {Data: []byte("not a program\n")},
+		"docs/scan.pdf":     {Data: []byte("\x00\x01\x02\x03")},
+		"images/fake.jpg":   {Data: []byte("just some text\n")},
+		"empty/placeholder": {Data: []byte{}},
+	}
+}
+
+func TestInventory(t *testing.T) {
+	var calls []Progress
+	report, err := Inventory(
+		context.Background(), inventoryFS(t), WithWorkers(3), WithProgress(
+			func(p Progress) {
+				calls = append(calls, p)
+			},
+		),
+	)
+	if err != nil {
+		t.Fatalf("Inventory() error = %v", err)
+	}
+
+	tests := []struct {
+		path          string
+		wantMediaType string
+		wantMismatch  bool
+		wantUnknown   bool
+		wantDangerous bool
+	}{
+		{path: "blobs/data", wantMediaType: "application/octet-stream", wantUnknown: true},
+		{path: "blobs/setup.exe", wantMediaType: "application/vnd.microsoft.portable-executable", wantDangerous: true},
+		{path: "blobs/tool.exe", wantMediaType: "application/vnd.microsoft.portable-executable", wantMismatch: true, wantDangerous: true},
+		{path: "docs/letter.docx", wantMediaType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{path: "docs/notes.txt", wantMediaType: "text/plain; charset=utf-8"},
+		{path: "docs/report.pdf", wantMediaType: "application/pdf"},
+		{path: "docs/scan.pdf", wantMediaType: "application/pdf", wantMismatch: true},
+		{path: "empty/placeholder", wantMediaType: "text/plain; charset=utf-8"},
+		{path: "images/fake.jpg", wantMediaType: "image/jpeg", wantMismatch: true},
+		{path: "images/logo.png", wantMediaType: "image/png"},
+		{path: "images/photo.jpg", wantMediaType: "application/pdf", wantMismatch: true},
+		{path: "web/app.js", wantMediaType: "text/javascript; charset=utf-8", wantDangerous: true},
+		{path: "web/index.html", wantMediaType: "text/html; charset=utf-8", wantDangerous: true},
+	}
+	if len(report.Files) != len(tests) {
+		t.Fatalf("Inventory() found %d files, want %d", len(report.Files), len(tests))
+	}
+	for i, tt := range tests {
+		f := report.Files[i]
+		if f.Path != tt.path || f.MediaType.ContentType() != tt.wantMediaType ||
+			f.Mismatch != tt.wantMismatch || f.Unknown != tt.wantUnknown || f.Dangerous != tt.wantDangerous {
+			t.Errorf(
+				"file %d = %s %s mismatch=%v unknown=%v dangerous=%v, want %s %s mismatch=%v unknown=%v dangerous=%v",
+				i, f.Path, f.MediaType.ContentType(), f.Mismatch, f.Unknown, f.Dangerous,
+				tt.path, tt.wantMediaType, tt.wantMismatch, tt.wantUnknown, tt.wantDangerous,
+			)
+		}
+	}
+
+	if report.Mismatches != 4 || report.Unknown != 1 || report.Dangerous != 4 || report.Errors != 0 {
+		t.Errorf(
+			"counts = %d mismatches, %d unknown, %d dangerous, %d errors, want 4, 1, 4, 0",
+			report.Mismatches, report.Unknown, report.Dangerous, report.Errors,
+		)
+	}
+	if report.MediaTypes["application/pdf"] != 3 || report.TopLevelTypes["text"] != 4 {
+		t.Errorf("counts = %v %v", report.MediaTypes, report.TopLevelTypes)
+	}
+	if len(calls) != len(tests) || calls[len(calls)-1].Files != len(tests) {
+		t.Errorf("progress was called %d times, last with %+v", len(calls), calls[len(calls)-1])
+	}
+}
+
+func TestInventory_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Inventory(ctx, inventoryFS(t)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Inventory() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestInventoryReport_Write(t *testing.T) {
+	report, err := Inventory(context.Background(), inventoryFS(t))
+	if err != nil {
+		t.Fatalf("Inventory() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded struct {
+		Files []struct {
+			Path      string `json:"path"`
+			MediaType string `json:"mediaType"`
+		} `json:"files"`
+		Mismatches int `json:"mismatches"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteJSON() wrote invalid JSON: %v", err)
+	}
+	if len(decoded.Files) != len(report.Files) || decoded.Files[0].MediaType != "application/octet-stream" || decoded.Mismatches != 4 {
+		t.Errorf("WriteJSON() = %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("WriteCSV() wrote invalid CSV: %v", err)
+	}
+	if len(records) != len(report.Files)+1 || records[0][0] != "path" || records[11][0] != "images/photo.jpg" || records[11][5] != "true" {
+		t.Errorf("WriteCSV() = %v", records)
+	}
+}
@@ -0,0 +1,279 @@
+package mediatypes
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// maxJSONDepth is the deepest nesting of arrays and objects DetectJSON
+// accepts.
+const maxJSONDepth = 64
+
+// errJSONTooDeep is returned when a JSON document is nested more deeply than
+// maxJSONDepth.
+var errJSONTooDeep = errors.New("mediatypes: JSON document nested too deeply")
+
+// jsonSummary holds the parts of a JSON document that identify its type.
+type jsonSummary struct {
+	// object is true if the document is an object, and array is true if it
+	// is an array.
+	object bool
+	array  bool
+
+	// keys holds the keys of a top-level object, and values the values of
+	// those keys that are strings.
+	keys   map[string]bool
+	values map[string]string
+
+	// contexts holds the strings in the "@context" of a top-level object.
+	contexts []string
+
+	// elementKeys holds the keys of the objects in a top-level array.
+	elementKeys []map[string]bool
+}
+
+// has returns true if the top-level object has all of the given keys.
+func (s *jsonSummary) has(keys ...string) bool {
+	for _, k := range keys {
+		if !s.keys[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectJSON returns the media type of a JSON document, such as
+// "application/geo+json" or "application/problem+json", by applying
+// key-based rules to its top-level value. It returns "application/json" for
+// other JSON documents, and false if the content is not JSON or is nested
+// more than 64 levels deep. The document is read as a stream; if it ends
+// early, as a prefix of a larger document does, the rules are applied to what
+// was read.
+func DetectJSON(r io.Reader) (MediaType, bool) {
+	s, err := summarizeJSON(r)
+	if err != nil {
+		return MediaType{}, false
+	}
+	for _, rule := range jsonRules {
+		if rule.match(s) {
+			return lookup(rule.name), true
+		}
+	}
+	return lookup("application/json"), true
+}
+
+// summarizeJSON reads a JSON document and returns its summary.
+func summarizeJSON(r io.Reader) (*jsonSummary, error) {
+	type frame struct {
+		object  bool
+		key     string
+		wantKey bool
+		index   int
+	}
+	s := &jsonSummary{
+		keys:   make(map[string]bool),
+		values: make(map[string]string),
+	}
+	var stack []frame
+
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	for {
+		token, err := d.Token()
+		if err != nil {
+			// A document that is cut short is still classified.
+			if (err == io.EOF || err == io.ErrUnexpectedEOF) && (s.object || s.array) {
+				return s, nil
+			}
+			return nil, err
+		}
+
+		depth := len(stack)
+		if depth > 0 {
+			top := &stack[depth-1]
+			if top.object && top.wantKey {
+				if key, ok := token.(string); ok {
+					top.key = key
+					top.wantKey = false
+					switch {
+					case depth == 1:
+						s.keys[key] = true
+					case depth == 2 && s.array && !stack[0].object:
+						s.elementKeys[len(s.elementKeys)-1][key] = true
+					}
+					continue
+				}
+			}
+			if top.object {
+				top.wantKey = true
+			}
+		}
+
+		inContext := depth >= 1 && stack[0].object && stack[0].key == "@context"
+		switch t := token.(type) {
+		case json.Delim:
+			switch t {
+			case '{', '[':
+				if depth == 0 {
+					s.object = t == '{'
+					s.array = t == '['
+				}
+				if depth == 1 && s.array && t == '{' {
+					s.elementKeys = append(s.elementKeys, make(map[string]bool))
+				}
+				if depth >= maxJSONDepth {
+					return nil, errJSONTooDeep
+				}
+				stack = append(stack, frame{object: t == '{', wantKey: t == '{'})
+			case '}', ']':
+				stack = stack[:depth-1]
+				if len(stack) == 0 {
+					// Reject trailing content after the document.
+					if _, err := d.Token(); err != io.EOF {
+						return nil, errors.New("mediatypes: trailing data after JSON document")
+					}
+					return s, nil
+				}
+			}
+		case string:
+			if depth == 1 && stack[0].object {
+				s.values[stack[0].key] = t
+			}
+			if inContext {
+				s.contexts = append(s.contexts, t)
+			}
+		default:
+			if depth == 0 {
+				// A bare scalar is valid JSON but carries no type
+				// information; require an object or array.
+				return nil, errors.New("mediatypes: JSON document is not an object or array")
+			}
+		}
+	}
+}
+
+// geoJSONTypes holds the values of the "type" member of GeoJSON objects.
+var geoJSONTypes = map[string]bool{
+	"Feature":            true,
+	"FeatureCollection":  true,
+	"GeometryCollection": true,
+	"LineString":         true,
+	"MultiLineString":    true,
+	"MultiPoint":         true,
+	"MultiPolygon":       true,
+	"Point":              true,
+	"Polygon":            true,
+}
+
+// jsonRules maps JSON documents to media type names, most specific first.
+var jsonRules = []struct {
+	name  string
+	match func(s *jsonSummary) bool
+}{
+	{
+		// Activity Streams 2.0 is JSON-LD with a well-known context.
+		name: "application/activity+json",
+		match: func(s *jsonSummary) bool {
+			for _, c := range s.contexts {
+				if strings.TrimSuffix(c, "#") == "https://www.w3.org/ns/activitystreams" {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	{
+		name: "application/ld+json",
+		match: func(s *jsonSummary) bool {
+			return s.has("@context")
+		},
+	},
+	{
+		name: "application/geo+json",
+		match: func(s *jsonSummary) bool {
+			return geoJSONTypes[s.values["type"]] &&
+				(s.keys["features"] || s.keys["geometry"] || s.keys["coordinates"] || s.keys["geometries"])
+		},
+	},
+	{
+		// JSON Feed is not in the registry yet, so this yields a media type
+		// with just the name.
+		name: "application/feed+json",
+		match: func(s *jsonSummary) bool {
+			return strings.HasPrefix(s.values["version"], "https://jsonfeed.org/version/")
+		},
+	},
+	{
+		name: "application/vnd.cyclonedx+json",
+		match: func(s *jsonSummary) bool {
+			return s.values["bomFormat"] == "CycloneDX"
+		},
+	},
+	{
+		name: "application/sarif+json",
+		match: func(s *jsonSummary) bool {
+			return s.has("runs") && strings.Contains(s.values["$schema"], "sarif")
+		},
+	},
+	{
+		name: "application/scim+json",
+		match: func(s *jsonSummary) bool {
+			return s.has("schemas")
+		},
+	},
+	{
+		name: "application/jwk-set+json",
+		match: func(s *jsonSummary) bool {
+			return s.has("keys") && len(s.keys) == 1
+		},
+	},
+	{
+		name: "application/jwk+json",
+		match: func(s *jsonSummary) bool {
+			return s.values["kty"] != ""
+		},
+	},
+	{
+		name: "application/vnd.hal+json",
+		match: func(s *jsonSummary) bool {
+			return s.has("_links")
+		},
+	},
+	{
+		name: "application/vnd.api+json",
+		match: func(s *jsonSummary) bool {
+			return s.has("jsonapi") || (s.has("data") && (s.has("links") || s.has("included")))
+		},
+	},
+	{
+		// RFC 7807 problem details have a title or detail, and a status.
+		name: "application/problem+json",
+		match: func(s *jsonSummary) bool {
+			return (s.has("title") || s.has("detail")) && s.has("status")
+		},
+	},
+	{
+		name: "application/manifest+json",
+		match: func(s *jsonSummary) bool {
+			return (s.has("name") || s.has("short_name")) &&
+				(s.has("start_url") || s.has("icons") || s.has("display"))
+		},
+	},
+	{
+		// RFC 6902 patches are arrays of operations with "op" and "path".
+		name: "application/json-patch+json",
+		match: func(s *jsonSummary) bool {
+			if !s.array || len(s.elementKeys) == 0 {
+				return false
+			}
+			for _, keys := range s.elementKeys {
+				if !keys["op"] || !keys["path"] {
+					return false
+				}
+			}
+			return true
+		},
+	},
+}
@@ -0,0 +1,99 @@
+package mediatypes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "geojson",
+			data:   `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": null}]}`,
+			want:   "application/geo+json",
+			wantOk: true,
+		},
+		{
+			name:   "json-ld",
+			data:   `{"@context": "https://schema.org", "@type": "Person", "name": "Jane"}`,
+			want:   "application/ld+json",
+			wantOk: true,
+		},
+		{
+			name:   "activity streams",
+			data:   `{"@context": ["https://www.w3.org/ns/activitystreams", {"@language": "en"}], "type": "Note"}`,
+			want:   "application/activity+json",
+			wantOk: true,
+		},
+		{
+			name:   "json patch",
+			data:   `[{"op": "add", "path": "/a", "value": 1}, {"op": "remove", "path": "/b"}]`,
+			want:   "application/json-patch+json",
+			wantOk: true,
+		},
+		{
+			name:   "problem details",
+			data:   `{"type": "https://example.com/probs/out-of-credit", "title": "Out of credit", "status": 403}`,
+			want:   "application/problem+json",
+			wantOk: true,
+		},
+		{
+			name:   "hal",
+			data:   `{"_links": {"self": {"href": "/orders/1"}}, "total": 30}`,
+			want:   "application/vnd.hal+json",
+			wantOk: true,
+		},
+		{
+			name:   "json feed",
+			data:   `{"version": "https://jsonfeed.org/version/1.1", "title": "Blog", "items": []}`,
+			want:   "application/feed+json",
+			wantOk: true,
+		},
+		{
+			name:   "plain object",
+			data:   `{"a": [1, 2, {"op": "x"}]}`,
+			want:   "application/json",
+			wantOk: true,
+		},
+		{
+			name:   "nested key is not top-level",
+			data:   `{"data": {"@context": "x"}}`,
+			want:   "application/json",
+			wantOk: true,
+		},
+		{
+			name:   "truncated",
+			data:   `{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1`,
+			want:   "application/geo+json",
+			wantOk: true,
+		},
+		{name: "syntax error", data: `{"a": }`},
+		{name: "scalar", data: `42`},
+		{name: "trailing data", data: `{} {}`},
+		{name: "text", data: `[1] see reference`},
+		{name: "too deep", data: strings.Repeat("[", maxJSONDepth+1) + strings.Repeat("]", maxJSONDepth+1)},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, ok := DetectJSON(strings.NewReader(tt.data))
+				if ok != tt.wantOk || got.Name() != tt.want {
+					t.Errorf("DetectJSON() = %s, %v, want %s, %v", got.Name(), ok, tt.want, tt.wantOk)
+				}
+			},
+		)
+	}
+}
+
+func TestJSONRules_Registered(t *testing.T) {
+	for _, rule := range jsonRules {
+		if _, ok := ByName(rule.name); !ok && rule.name != "application/feed+json" {
+			t.Errorf("JSON rule maps to unknown media type %s", rule.name)
+		}
+	}
+}
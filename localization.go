@@ -0,0 +1,211 @@
+package mediatypes
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// translations holds the translated descriptions added with AddTranslations,
+// keyed by canonical language tag and then by lower-cased media type name.
+var translations = struct {
+	sync.RWMutex
+	m map[string]map[string]string
+}{m: make(map[string]map[string]string)}
+
+// DescriptionIn returns the description of the media type in the given
+// language, such as "de" or "pt-BR". Language tags are matched using the
+// BCP 47 lookup fallback chain, so "de-CH-1996" falls back to "de-CH" and
+// then "de". If no translation is found, DescriptionIn returns Description.
+func (m MediaType) DescriptionIn(lang string) string {
+	name := strings.ToLower(m.name)
+	tag, err := canonicalLanguageTag(lang)
+	if err == nil {
+		translations.RLock()
+		defer translations.RUnlock()
+		for _, candidate := range languageFallbacks(tag) {
+			if d, ok := translations.m[candidate][name]; ok {
+				return d
+			}
+		}
+	}
+	return m.Description()
+}
+
+// AddTranslations adds descriptions in the given language, keyed by media
+// type name, to the registry. Descriptions replace any earlier translations
+// for the same language and media type. AddTranslations is safe to call
+// concurrently with lookups.
+func AddTranslations(lang string, descriptions map[string]string) error {
+	tag, err := canonicalLanguageTag(lang)
+	if err != nil {
+		return err
+	}
+	addBundles(map[string]map[string]string{tag: descriptions})
+	return nil
+}
+
+// addBundles adds descriptions keyed by canonical language tag and then by
+// media type name, all at once.
+func addBundles(bundles map[string]map[string]string) {
+	translations.Lock()
+	defer translations.Unlock()
+	for tag, descriptions := range bundles {
+		bundle, ok := translations.m[tag]
+		if !ok {
+			bundle = make(map[string]string, len(descriptions))
+			translations.m[tag] = bundle
+		}
+		for name, d := range descriptions {
+			bundle[strings.ToLower(name)] = d
+		}
+	}
+}
+
+// sharedMIMEInfo is the subset of the shared-mime-info database format that
+// holds descriptions.
+type sharedMIMEInfo struct {
+	MIMETypes []struct {
+		Type     string `xml:"type,attr"`
+		Comments []struct {
+			Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"comment"`
+	} `xml:"mime-type"`
+}
+
+// LoadSharedMIMEInfo reads a shared-mime-info database, such as
+// freedesktop.org.xml, and adds the translated comments it contains to the
+// registry. Comments without an xml:lang attribute are English and are added
+// as "en". Locale modifiers that name a script or a variant, such as
+// "sr@latin", are converted to subtags, as in "sr-Latn"; comments with other
+// modifiers are skipped. Either all comments are added or, if the database is
+// invalid, none are.
+func LoadSharedMIMEInfo(r io.Reader) error {
+	var info sharedMIMEInfo
+	if err := xml.NewDecoder(r).Decode(&info); err != nil {
+		return fmt.Errorf("mediatypes: invalid shared-mime-info database: %v", err)
+	}
+
+	bundles := make(map[string]map[string]string)
+	for _, t := range info.MIMETypes {
+		for _, c := range t.Comments {
+			lang := c.Lang
+			if lang == "" {
+				lang = "en"
+			}
+			lang, ok := localeLanguageTag(lang)
+			if !ok {
+				continue
+			}
+			tag, err := canonicalLanguageTag(lang)
+			if err != nil {
+				return err
+			}
+			if bundles[tag] == nil {
+				bundles[tag] = make(map[string]string)
+			}
+			bundles[tag][t.Type] = strings.TrimSpace(c.Text)
+		}
+	}
+	addBundles(bundles)
+	return nil
+}
+
+// localeModifiers maps the modifiers of POSIX locale names, as in
+// "sr@latin", to the script or variant subtags of language tags.
+var localeModifiers = map[string]struct {
+	subtag string
+	script bool
+}{
+	"cyrillic":   {subtag: "Cyrl", script: true},
+	"devanagari": {subtag: "Deva", script: true},
+	"latin":      {subtag: "Latn", script: true},
+	"valencia":   {subtag: "valencia"},
+}
+
+// localeLanguageTag converts a POSIX locale name, such as "pt_BR" or
+// "uz_UZ@cyrillic", to a language tag, such as "pt_BR" or "uz-Cyrl_UZ", whose
+// separators canonicalLanguageTag normalizes. It returns false if the locale
+// has a modifier that has no subtag.
+func localeLanguageTag(locale string) (string, bool) {
+	i := strings.IndexByte(locale, '@')
+	if i < 0 {
+		return locale, true
+	}
+	lang, modifier := locale[:i], locale[i+1:]
+	m, ok := localeModifiers[strings.ToLower(modifier)]
+	if !ok {
+		return "", false
+	}
+	if !m.script {
+		return lang + "-" + m.subtag, true
+	}
+	if j := strings.IndexAny(lang, "_-"); j >= 0 {
+		return lang[:j] + "-" + m.subtag + lang[j:], true
+	}
+	return lang + "-" + m.subtag, true
+}
+
+// canonicalLanguageTag validates a BCP 47 language tag and returns it in
+// canonical case, such as "zh-Hant-TW". Underscores are accepted as
+// separators.
+func canonicalLanguageTag(lang string) (string, error) {
+	subtags := strings.Split(strings.Replace(strings.TrimSpace(lang), "_", "-", -1), "-")
+	for i, s := range subtags {
+		if len(s) == 0 || len(s) > 8 || !isAlphanumeric(s) || (i == 0 && !isAlpha(s)) {
+			return "", fmt.Errorf("mediatypes: invalid language tag %q", lang)
+		}
+		s = strings.ToLower(s)
+		switch {
+		case i == 0:
+		case len(s) == 2 && isAlpha(s):
+			s = strings.ToUpper(s)
+		case len(s) == 4 && isAlpha(s):
+			s = strings.ToUpper(s[:1]) + s[1:]
+		}
+		subtags[i] = s
+	}
+	return strings.Join(subtags, "-"), nil
+}
+
+// languageFallbacks returns the lookup fallback chain of a canonical
+// language tag, as described in RFC 4647 section 3.4.
+func languageFallbacks(tag string) []string {
+	chain := []string{tag}
+	for {
+		i := strings.LastIndexByte(tag, '-')
+		if i < 0 {
+			return chain
+		}
+		tag = tag[:i]
+		// Drop a trailing singleton, which would introduce an extension.
+		if j := strings.LastIndexByte(tag, '-'); j >= 0 && len(tag)-j == 2 {
+			tag = tag[:j]
+		}
+		chain = append(chain, tag)
+	}
+}
+
+// isAlpha returns true if s consists of ASCII letters only.
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isAlphanumeric returns true if s consists of ASCII letters and digits only.
+func isAlphanumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && !isAlpha(s[i:i+1]) {
+			return false
+		}
+	}
+	return true
+}
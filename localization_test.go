@@ -0,0 +1,122 @@
+package mediatypes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// resetTranslations removes the translations for the duration of the test.
+func resetTranslations(t *testing.T) {
+	translations.Lock()
+	saved := translations.m
+	translations.m = make(map[string]map[string]string)
+	translations.Unlock()
+	t.Cleanup(
+		func() {
+			translations.Lock()
+			translations.m = saved
+			translations.Unlock()
+		},
+	)
+}
+
+func TestMediaType_DescriptionIn(t *testing.T) {
+	resetTranslations(t)
+	err := LoadSharedMIMEInfo(
+		strings.NewReader(
+			`<?xml version="1.0" encoding="UTF-8"?>
+<mime-info xmlns="http://www.freedesktop.org/standards/shared-mime-info">
+  <mime-type type="application/pdf">
+    <comment>PDF document</comment>
+    <comment xml:lang="de">PDF-Dokument</comment>
+    <comment xml:lang="pt_BR">Documento PDF</comment>
+    <comment xml:lang="sr">PDF документ</comment>
+    <comment xml:lang="sr@latin">PDF dokument</comment>
+    <comment xml:lang="uz_UZ@cyrillic">PDF ҳужжати</comment>
+    <comment xml:lang="ca@valencia">Document PDF (valencià)</comment>
+    <comment xml:lang="de@euro">PDF-Dokument (Euro)</comment>
+  </mime-type>
+</mime-info>`,
+		),
+	)
+	if err != nil {
+		t.Fatalf("LoadSharedMIMEInfo() error = %v", err)
+	}
+	if err := AddTranslations("fr", map[string]string{"Application/PDF": "Document PDF"}); err != nil {
+		t.Fatalf("AddTranslations() error = %v", err)
+	}
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{lang: "de", want: "PDF-Dokument"},
+		{lang: "de-CH-1996", want: "PDF-Dokument"},
+		{lang: "pt-br", want: "Documento PDF"},
+		{lang: "pt", want: "PDF document"},
+		{lang: "sr", want: "PDF документ"},
+		{lang: "sr-Latn", want: "PDF dokument"},
+		{lang: "sr-Latn-RS", want: "PDF dokument"},
+		{lang: "uz-Cyrl-UZ", want: "PDF ҳужжати"},
+		{lang: "ca-valencia", want: "Document PDF (valencià)"},
+		{lang: "fr-CA", want: "Document PDF"},
+		{lang: "ja", want: "PDF document"},
+		{lang: "not a tag", want: "PDF document"},
+	}
+	m, _ := ByName("application/pdf")
+	for _, tt := range tests {
+		t.Run(
+			tt.lang, func(t *testing.T) {
+				if got := m.DescriptionIn(tt.lang); got != tt.want {
+					t.Errorf("DescriptionIn(%q) = %q, want %q", tt.lang, got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestLoadSharedMIMEInfo_Atomic(t *testing.T) {
+	resetTranslations(t)
+	err := LoadSharedMIMEInfo(
+		strings.NewReader(
+			`<mime-info>
+  <mime-type type="application/pdf">
+    <comment xml:lang="de">PDF-Dokument</comment>
+    <comment xml:lang="1x">PDF</comment>
+  </mime-type>
+</mime-info>`,
+		),
+	)
+	if err == nil {
+		t.Fatal("LoadSharedMIMEInfo() error = nil, want an error")
+	}
+	m, _ := ByName("application/pdf")
+	if got := m.DescriptionIn("de"); got != m.Description() {
+		t.Errorf("DescriptionIn(\"de\") = %q after a failed load, want %q", got, m.Description())
+	}
+}
+
+func TestAddTranslations_InvalidTag(t *testing.T) {
+	for _, lang := range []string{"", "1de", "de--CH", "toolongsubtag"} {
+		if err := AddTranslations(lang, nil); err == nil {
+			t.Errorf("AddTranslations(%q) error = nil, want error", lang)
+		}
+	}
+}
+
+func TestLanguageFallbacks(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{tag: "de", want: []string{"de"}},
+		{tag: "zh-Hant-TW", want: []string{"zh-Hant-TW", "zh-Hant", "zh"}},
+		{tag: "en-US-x-twain", want: []string{"en-US-x-twain", "en-US", "en"}},
+	}
+	for _, tt := range tests {
+		if got := languageFallbacks(tt.tag); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("languageFallbacks(%q) = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,785 @@
+package mediatypes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultMagicRange is the number of bytes a regex test considers if the
+// rule does not say.
+const defaultMagicRange = 8192
+
+// MagicDiagnostic reports a line of a magic file that was not compiled.
+type MagicDiagnostic struct {
+	// Line is the line number, starting at 1.
+	Line int
+
+	// Text is the line itself.
+	Text string
+
+	// Message describes the problem.
+	Message string
+}
+
+// String returns the diagnostic in the form "line 12: message: text".
+func (d MagicDiagnostic) String() string {
+	return fmt.Sprintf("line %d: %s: %s", d.Line, d.Message, d.Text)
+}
+
+// magicKind is the kind of test of a magic line.
+type magicKind int
+
+const (
+	magicNumber magicKind = iota
+	magicString
+	magicSearch
+	magicRegex
+)
+
+// magicOffset is the offset of a magic line.
+type magicOffset struct {
+	// base is the offset. If relative is set, the end of the parent match
+	// is added to it, or to the number read through it if it is indirect.
+	base     int64
+	relative bool
+
+	// If indirect is set, the offset is read from the content at base, or
+	// at base after the end of the parent match if indirectRelative is set:
+	// a number of indirectSize bytes, plus adjust.
+	indirect         bool
+	indirectRelative bool
+	indirectSize     int
+	indirectOrder    binary.ByteOrder
+	indirectSigned   bool
+	adjust           int64
+}
+
+// magicLine is a compiled test of a magic file.
+type magicLine struct {
+	level  int
+	offset magicOffset
+	kind   magicKind
+
+	// Numeric tests compare a number of size bytes, ANDed with mask if it is
+	// not zero, to value using op. If any is set, every number matches.
+	size     int
+	order    binary.ByteOrder
+	unsigned bool
+	mask     uint64
+	op       byte
+	value    uint64
+	any      bool
+
+	// String and search tests compare str, and search tests look for it in
+	// the searchRange bytes after the offset. The flags modify the
+	// comparison; negate inverts it.
+	str         []byte
+	searchRange int
+	ignoreLower bool
+	ignoreUpper bool
+	negate      bool
+	regex       *regexp.Regexp
+	regexLines  bool
+	regexRange  int
+}
+
+// resolve returns the absolute offset of the line, given the end of the
+// parent match.
+func (o magicOffset) resolve(data []byte, parentEnd int) (int, bool) {
+	v := o.base
+	if o.indirect {
+		// In "(&4.l)" the number is read after the parent match.
+		if o.indirectRelative {
+			v += int64(parentEnd)
+		}
+		if v < 0 || v+int64(o.indirectSize) > int64(len(data)) {
+			return 0, false
+		}
+		n := readUint(data[v:], o.indirectSize, o.indirectOrder)
+		v = int64(n)
+		if o.indirectSigned {
+			v = signExtend(n, o.indirectSize)
+		}
+		v += o.adjust
+	}
+	// In "&4" and "&(4.l)" the offset itself is relative.
+	if o.relative {
+		v += int64(parentEnd)
+	}
+	return int(v), v >= 0
+}
+
+// match tests data, given the end of the parent match, and returns the end
+// of this match.
+func (l *magicLine) match(data []byte, parentEnd int) (int, bool) {
+	offset, ok := l.offset.resolve(data, parentEnd)
+	if !ok || offset > len(data) {
+		return 0, false
+	}
+	switch l.kind {
+	case magicNumber:
+		if offset+l.size > len(data) {
+			return 0, false
+		}
+		if l.any {
+			return offset + l.size, true
+		}
+		n := readUint(data[offset:], l.size, l.order)
+		if l.mask != 0 {
+			n &= l.mask
+		}
+		return offset + l.size, compareNumbers(n, l.value, l.op, l.size, l.unsigned)
+	case magicString:
+		if l.any {
+			return offset, true
+		}
+		matched := offset+len(l.str) <= len(data) && l.equalFold(data[offset:offset+len(l.str)])
+		return offset + len(l.str), matched != l.negate
+	case magicSearch:
+		end := offset + l.searchRange + len(l.str)
+		if end > len(data) {
+			end = len(data)
+		}
+		for i := offset; i+len(l.str) <= end; i++ {
+			if l.equalFold(data[i : i+len(l.str)]) {
+				return i + len(l.str), !l.negate
+			}
+		}
+		return offset, l.negate
+	case magicRegex:
+		window := data[offset:]
+		if l.regexLines {
+			lines := 0
+			for i, c := range window {
+				if c == '\n' {
+					lines++
+					if lines == l.regexRange {
+						window = window[:i+1]
+						break
+					}
+				}
+			}
+		} else if len(window) > l.regexRange {
+			window = window[:l.regexRange]
+		}
+		loc := l.regex.FindIndex(window)
+		if loc == nil {
+			return offset, l.negate
+		}
+		return offset + loc[1], !l.negate
+	}
+	return 0, false
+}
+
+// equalFold compares b to the string of the line, honoring the case
+// flags.
+func (l *magicLine) equalFold(b []byte) bool {
+	for i, p := range l.str {
+		c := b[i]
+		switch {
+		case c == p:
+		case l.ignoreLower && p >= 'a' && p <= 'z' && c == p-'a'+'A':
+		case l.ignoreUpper && p >= 'A' && p <= 'Z' && c == p-'A'+'a':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// magicPath matches if each line matches, in order, with relative offsets
+// taken from the end of the previous match.
+type magicPath []*magicLine
+
+func (p magicPath) match(data []byte) bool {
+	end := 0
+	for _, l := range p {
+		e, ok := l.match(data, end)
+		if !ok {
+			return false
+		}
+		end = e
+	}
+	return true
+}
+
+// magicMatch is a media type named by a !:mime annotation, with the lines
+// that must match for it.
+type magicMatch struct {
+	path  magicPath
+	level int
+	name  string
+}
+
+// MagicPriority is the priority of signatures parsed by ParseMagic. It is
+// below DefaultPriority, so that magic files, which often have broad rules,
+// only recognize content that the built-in detection does not.
+const MagicPriority = DefaultPriority - 10
+
+// applyStrength applies a !:strength annotation, such as "+10" or "*2", to a
+// priority. The result stays below DefaultPriority.
+func applyStrength(priority int, annotation string) (int, error) {
+	annotation = strings.TrimSpace(annotation)
+	if annotation == "" {
+		return 0, fmt.Errorf("missing strength")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(annotation[1:]))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid strength %s", annotation)
+	}
+	switch annotation[0] {
+	case '+':
+		priority += n
+	case '-':
+		priority -= n
+	case '*':
+		priority *= n
+	case '/':
+		if n == 0 {
+			return 0, fmt.Errorf("invalid strength %s", annotation)
+		}
+		priority /= n
+	default:
+		return 0, fmt.Errorf("invalid strength %s", annotation)
+	}
+	switch {
+	case priority < 0:
+		priority = 0
+	case priority >= DefaultPriority:
+		priority = DefaultPriority - 1
+	}
+	return priority, nil
+}
+
+// ParseMagic parses signatures in the magic(5) format used by libmagic and
+// the file command. It supports byte, short, long and quad tests in native,
+// big-endian and little-endian byte order, optionally unsigned and masked,
+// with the =, !, <, >, & and ^ operators; string, search and regex tests with
+// the c and C flags; absolute, relative and indirect offsets; continuation
+// levels; !:mime annotations, which name the media type of a match; and
+// !:strength annotations. A continuation line matches only if the lines it
+// continues match. Native byte order is taken to be little-endian.
+//
+// The signatures have MagicPriority, adjusted by !:strength, but never reach
+// DefaultPriority, so they only take part if the built-in detection does not
+// recognize the content.
+//
+// Lines that use unsupported constructs are not compiled, together with
+// their continuations, and are reported in the returned diagnostics. The
+// returned error is only set if r cannot be read.
+func ParseMagic(r io.Reader) (*Signatures, []MagicDiagnostic, error) {
+	var (
+		diagnostics []MagicDiagnostic
+		entries     [][]signature
+		// path holds the compiled lines of the current entry by level; a nil
+		// element means the line at that level was not compiled.
+		path []*magicLine
+		// pending holds the media type names of the lines of the current
+		// entry, in file order.
+		pending []magicMatch
+		last    *magicLine
+		// priority is the priority of the current entry.
+		priority = MagicPriority
+	)
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		// Deeper, more specific matches come first.
+		sort.SliceStable(
+			pending, func(i, j int) bool {
+				return pending[i].level > pending[j].level
+			},
+		)
+		var entry []signature
+		for _, p := range pending {
+			entry = append(entry, signature{name: p.name, priority: priority, matcher: p.path})
+		}
+		entries = append(entries, entry)
+		pending = pending[:0]
+	}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		report := func(message string) {
+			diagnostics = append(diagnostics, MagicDiagnostic{Line: n, Text: text, Message: message})
+		}
+		switch {
+		case trimmed == "" || trimmed[0] == '#':
+			continue
+		case strings.HasPrefix(trimmed, "!:"):
+			fields := strings.Fields(trimmed[2:])
+			switch {
+			case len(fields) == 0:
+				report("empty annotation")
+			case fields[0] == "mime":
+				if last == nil {
+					continue
+				}
+				if len(fields) < 2 {
+					report("missing media type")
+					continue
+				}
+				name, _, err := parseMediaTypeName(fields[1])
+				if err != nil {
+					report(err.Error())
+					continue
+				}
+				if _, ok := ByName(name); !ok {
+					report("media type " + name + " is not in the registry")
+				}
+				p := make(magicPath, last.level+1)
+				copy(p, path[:last.level+1])
+				pending = append(pending, magicMatch{path: p, level: last.level, name: name})
+			case fields[0] == "strength":
+				if last == nil {
+					continue
+				}
+				p, err := applyStrength(priority, strings.Join(fields[1:], ""))
+				if err != nil {
+					report(err.Error())
+					continue
+				}
+				priority = p
+			case fields[0] == "apple" || fields[0] == "ext":
+				// Metadata that does not affect matching.
+			default:
+				report("unsupported annotation !:" + fields[0])
+			}
+			continue
+		}
+
+		level := 0
+		for level < len(text) && text[level] == '>' {
+			level++
+		}
+		if level == 0 {
+			flush()
+			path = path[:0]
+			priority = MagicPriority
+		}
+		if level > len(path) {
+			report("continuation without a parent")
+			last = nil
+			continue
+		}
+		path = path[:level]
+		if level > 0 && path[level-1] == nil {
+			// The parent was not compiled, so neither is its continuation.
+			path = append(path, nil)
+			last = nil
+			continue
+		}
+
+		l, err := parseMagicLine(text[level:])
+		if err != nil {
+			report(err.Error())
+			path = append(path, nil)
+			last = nil
+			continue
+		}
+		l.level = level
+		path = append(path, l)
+		last = l
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, diagnostics, err
+	}
+	flush()
+
+	s := &Signatures{}
+	for _, entry := range entries {
+		s.list = append(s.list, entry...)
+	}
+	return s, diagnostics, nil
+}
+
+// LoadMagic parses signatures in the magic(5) format, as described for
+// ParseMagic, and adds them to the detector.
+func LoadMagic(r io.Reader) ([]MagicDiagnostic, error) {
+	s, diagnostics, err := ParseMagic(r)
+	if err != nil {
+		return diagnostics, err
+	}
+	AddSignatures(s)
+	return diagnostics, nil
+}
+
+// magicTypes maps the numeric types of magic files to their size and byte
+// order. Each may be prefixed with "u" to compare unsigned.
+var magicTypes = map[string]struct {
+	size  int
+	order binary.ByteOrder
+}{
+	"byte":    {1, binary.LittleEndian},
+	"short":   {2, binary.LittleEndian},
+	"leshort": {2, binary.LittleEndian},
+	"beshort": {2, binary.BigEndian},
+	"long":    {4, binary.LittleEndian},
+	"lelong":  {4, binary.LittleEndian},
+	"belong":  {4, binary.BigEndian},
+	"quad":    {8, binary.LittleEndian},
+	"lequad":  {8, binary.LittleEndian},
+	"bequad":  {8, binary.BigEndian},
+}
+
+// parseMagicLine parses a magic line without its level prefix.
+func parseMagicLine(text string) (*magicLine, error) {
+	fields := splitMagicFields(text)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("expected offset, type and test")
+	}
+	l := &magicLine{}
+	offset, err := parseMagicOffset(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	l.offset = offset
+
+	typ, modifiers := fields[1], ""
+	if i := strings.IndexAny(typ, "&/"); i >= 0 {
+		typ, modifiers = typ[:i], typ[i:]
+	}
+	if t, ok := magicTypes[strings.TrimPrefix(typ, "u")]; ok {
+		l.kind, l.size, l.order = magicNumber, t.size, t.order
+		l.unsigned = strings.HasPrefix(typ, "u")
+		if modifiers != "" {
+			if modifiers[0] != '&' {
+				return nil, fmt.Errorf("unsupported modifier %s for %s", modifiers, typ)
+			}
+			mask, err := strconv.ParseUint(modifiers[1:], 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mask %s", modifiers[1:])
+			}
+			l.mask = mask
+		}
+		return l, parseMagicNumberTest(l, fields[2])
+	}
+
+	switch typ {
+	case "string":
+		l.kind = magicString
+	case "search":
+		l.kind = magicSearch
+	case "regex":
+		l.kind = magicRegex
+		l.regexRange = defaultMagicRange
+	default:
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+	if modifiers != "" && modifiers[0] != '/' {
+		return nil, fmt.Errorf("unsupported modifier %s for %s", modifiers, typ)
+	}
+	for _, m := range strings.Split(strings.TrimPrefix(modifiers, "/"), "/") {
+		if m == "" {
+			continue
+		}
+		// A modifier is a range, flags, or a range followed by flags as in
+		// "regex/100l".
+		digits := 0
+		for digits < len(m) && m[digits] >= '0' && m[digits] <= '9' {
+			digits++
+		}
+		if digits > 0 {
+			n, _ := strconv.Atoi(m[:digits])
+			if l.kind == magicSearch {
+				l.searchRange = n
+			} else if l.kind == magicRegex {
+				l.regexRange = n
+			} else {
+				return nil, fmt.Errorf("unsupported modifier %s for %s", m, typ)
+			}
+		}
+		for _, f := range m[digits:] {
+			switch f {
+			case 'c':
+				l.ignoreLower = true
+			case 'C':
+				l.ignoreUpper = true
+			case 'l':
+				if l.kind != magicRegex {
+					return nil, fmt.Errorf("unsupported flag %c for %s", f, typ)
+				}
+				l.regexLines = true
+			case 'b', 't', 'T', 's':
+				// Hints about the kind of content, which do not affect
+				// matching here.
+			default:
+				return nil, fmt.Errorf("unsupported flag %c for %s", f, typ)
+			}
+		}
+	}
+	if l.kind == magicSearch && l.searchRange == 0 {
+		return nil, fmt.Errorf("search without a range")
+	}
+
+	test := fields[2]
+	if test == "x" {
+		if l.kind != magicString {
+			return nil, fmt.Errorf("unsupported test x for %s", typ)
+		}
+		l.any = true
+		return l, nil
+	}
+	switch test[0] {
+	case '=':
+		test = test[1:]
+	case '!':
+		l.negate = true
+		test = test[1:]
+	case '<', '>':
+		return nil, fmt.Errorf("unsupported operator %c for %s", test[0], typ)
+	}
+	str, err := unescapeMagic(test)
+	if err != nil {
+		return nil, err
+	}
+	if len(str) == 0 {
+		return nil, fmt.Errorf("empty %s", typ)
+	}
+	if l.kind == magicRegex {
+		pattern := string(str)
+		if l.ignoreLower || l.ignoreUpper {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported regex: %v", err)
+		}
+		l.regex = re
+		return l, nil
+	}
+	l.str = str
+	return l, nil
+}
+
+// parseMagicNumberTest parses the test of a numeric magic line.
+func parseMagicNumberTest(l *magicLine, test string) error {
+	if test == "x" {
+		l.any = true
+		return nil
+	}
+	l.op = '='
+	if strings.IndexByte("=!<>&^~", test[0]) >= 0 {
+		l.op = test[0]
+		test = test[1:]
+	}
+	if l.op == '~' {
+		return fmt.Errorf("unsupported operator ~")
+	}
+	v, err := strconv.ParseInt(test, 0, 64)
+	if err != nil {
+		u, uerr := strconv.ParseUint(test, 0, 64)
+		if uerr != nil {
+			return fmt.Errorf("invalid number %s", test)
+		}
+		v = int64(u)
+	}
+	l.value = uint64(v)
+	if l.size < 8 {
+		l.value &= 1<<(8*uint(l.size)) - 1
+	}
+	return nil
+}
+
+// parseMagicOffset parses an offset such as "0", "0x3c", "&2", "(0x3c.l)",
+// "(4.S+8)", "&(2.b)" or "(&2.b)".
+func parseMagicOffset(s string) (magicOffset, error) {
+	var o magicOffset
+	if strings.HasPrefix(s, "&") {
+		o.relative = true
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "(") {
+		n, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return o, fmt.Errorf("unsupported offset %s", s)
+		}
+		o.base = n
+		return o, nil
+	}
+	if !strings.HasSuffix(s, ")") {
+		return o, fmt.Errorf("unsupported offset %s", s)
+	}
+	inner := s[1 : len(s)-1]
+	o.indirect = true
+	if strings.HasPrefix(inner, "&") {
+		o.indirectRelative = true
+		inner = inner[1:]
+	}
+	sep := strings.IndexAny(inner, ".,")
+	if sep < 0 || sep+1 >= len(inner) {
+		return o, fmt.Errorf("unsupported offset %s", s)
+	}
+	base, err := strconv.ParseInt(inner[:sep], 0, 64)
+	if err != nil {
+		return o, fmt.Errorf("unsupported offset %s", s)
+	}
+	o.base = base
+	o.indirectSigned = inner[sep] == ','
+	// Lower-case types are little-endian and upper-case types big-endian.
+	c := inner[sep+1]
+	switch c | 0x20 {
+	case 'b', 'c':
+		o.indirectSize = 1
+	case 's', 'h':
+		o.indirectSize = 2
+	case 'l':
+		o.indirectSize = 4
+	case 'q':
+		o.indirectSize = 8
+	default:
+		return o, fmt.Errorf("unsupported indirect type %c", c)
+	}
+	o.indirectOrder = binary.LittleEndian
+	if c >= 'A' && c <= 'Z' {
+		o.indirectOrder = binary.BigEndian
+	}
+	if rest := inner[sep+2:]; rest != "" {
+		if rest[0] != '+' && rest[0] != '-' {
+			return o, fmt.Errorf("unsupported offset arithmetic %s", rest)
+		}
+		adjust, err := strconv.ParseInt(rest, 0, 64)
+		if err != nil {
+			return o, fmt.Errorf("unsupported offset arithmetic %s", rest)
+		}
+		o.adjust = adjust
+	}
+	return o, nil
+}
+
+// splitMagicFields splits a magic line into whitespace separated fields,
+// honoring backslash escapes, so that "\ " does not end a field.
+func splitMagicFields(s string) []string {
+	var (
+		fields []string
+		field  strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			field.WriteByte(c)
+			field.WriteByte(s[i+1])
+			i++
+		case c == ' ' || c == '\t':
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+				// The fourth field is the message, which may contain spaces.
+				if len(fields) == 3 {
+					return fields
+				}
+			}
+		default:
+			field.WriteByte(c)
+		}
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+// unescapeMagic decodes the escapes of a magic string: \n, \r, \t, \\, \xNN,
+// octal \NNN and escaped characters such as "\ ".
+func unescapeMagic(s string) ([]byte, error) {
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = s[i]; {
+		case c == 'n':
+			b.WriteByte('\n')
+		case c == 'r':
+			b.WriteByte('\r')
+		case c == 't':
+			b.WriteByte('\t')
+		case c == 'x':
+			j := i + 1
+			for j < len(s) && j < i+3 && isHexDigit(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("invalid escape \\x")
+			}
+			n, _ := strconv.ParseUint(s[i+1:j], 16, 8)
+			b.WriteByte(byte(n))
+			i = j - 1
+		case c >= '0' && c <= '7':
+			j := i
+			for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			n, _ := strconv.ParseUint(s[i:j], 8, 16)
+			b.WriteByte(byte(n))
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// isHexDigit returns true if c is a hexadecimal digit.
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c|0x20 >= 'a' && c|0x20 <= 'f')
+}
+
+// readUint reads an unsigned number of the given size in bytes.
+func readUint(b []byte, size int, order binary.ByteOrder) uint64 {
+	switch size {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(order.Uint16(b))
+	case 4:
+		return uint64(order.Uint32(b))
+	}
+	return order.Uint64(b)
+}
+
+// signExtend interprets n as a signed number of the given size in bytes.
+func signExtend(n uint64, size int) int64 {
+	shift := uint(64 - 8*size)
+	return int64(n<<shift) >> shift
+}
+
+// compareNumbers compares a number read from the content to the value of a
+// test.
+func compareNumbers(n, value uint64, op byte, size int, unsigned bool) bool {
+	switch op {
+	case '=':
+		return n == value
+	case '!':
+		return n != value
+	case '&':
+		return n&value == value
+	case '^':
+		return n&value == 0
+	}
+	if unsigned {
+		if op == '<' {
+			return n < value
+		}
+		return n > value
+	}
+	a, b := signExtend(n, size), signExtend(value, size)
+	if op == '<' {
+		return a < b
+	}
+	return a > b
+}
@@ -0,0 +1,189 @@
+package mediatypes
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMagic = `# Test corpus
+0	string		GIF8		GIF image data
+!:mime	image/gif
+0	belong&0xfffffff0	0x89504e40	PNG-like
+>4	string		\r\n\x1a\n	PNG image data
+!:mime	image/png
+0	string		PK\003\004	Zip archive data
+!:mime	application/zip
+>30	string		mimetype
+>>38	string		application/epub+zip	EPUB document
+!:mime	application/epub+zip
+0	string		MZ		DOS executable
+>(0x3c.l)	string	PE\0\0		PE executable
+!:mime	application/vnd.microsoft.portable-executable
+0	search/64	\<svg		SVG image
+!:mime	image/svg+xml
+0	regex/2l	^#!/bin/(ba)?sh	shell script
+!:mime	application/x-shellscript
+0	string/c	solid\ 	ASCII STL
+>&0	ubyte		>0x20
+!:mime	model/stl
+0	leshort		0x1234
+>2	ushort		<10
+!:mime	application/x-sh
+0	name		part
+0	string		ABCD
+>4	float		1.5
+!:mime	application/x-unsupported
+>>8	string		EF
+!:mime	application/x-unsupported
+0	string		XY
+!:strength	+10
+0	string		RO
+>&(2.b)	string		ok	relative indirect offset
+!:mime	application/x-bcpio
+0	string		RI
+>(&1.b)	string		ok	indirect relative offset
+!:mime	application/x-bsh
+`
+
+func TestParseMagic(t *testing.T) {
+	s, diagnostics, err := ParseMagic(strings.NewReader(testMagic))
+	if err != nil {
+		t.Fatalf("ParseMagic() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "string", data: "GIF89a", want: "image/gif"},
+		{name: "masked number and continuation", data: "\x89PNG\r\n\x1a\n", want: "image/png"},
+		{name: "failed continuation", data: "\x89PNG\x00\x00\x00\x00", want: ""},
+		{name: "nested continuation", data: "PK\x03\x04" + strings.Repeat("\x00", 26) + "mimetypeapplication/epub+zip", want: "application/epub+zip"},
+		{name: "parent of continuation", data: "PK\x03\x04" + strings.Repeat("\x00", 26) + "mimetypetext/plain", want: "application/zip"},
+		{name: "indirect offset", data: "MZ" + strings.Repeat("\x00", 58) + "\x40\x00\x00\x00PE\x00\x00", want: "application/vnd.microsoft.portable-executable"},
+		{name: "indirect offset out of range", data: "MZ" + strings.Repeat("\x00", 58) + "\xff\x00\x00\x00", want: ""},
+		{name: "search", data: "<?xml version=\"1.0\"?>\n<svg>", want: "image/svg+xml"},
+		{name: "search out of range", data: strings.Repeat(" ", 80) + "<svg>", want: ""},
+		{name: "regex", data: "#!/bin/bash\n", want: "application/x-shellscript"},
+		{name: "case-insensitive string and relative offset", data: "SOLID model", want: "model/stl"},
+		{name: "relative offset fails", data: "solid \x01", want: ""},
+		{name: "unsigned comparison", data: "\x34\x12\x05\x00", want: "application/x-sh"},
+		{name: "unsigned comparison fails", data: "\x34\x12\xff\xff", want: ""},
+		{name: "relative indirect offset", data: "RO\x01ok", want: "application/x-bcpio"},
+		{name: "relative indirect offset fails", data: "RO\x03ok", want: ""},
+		{name: "indirect relative offset", data: "RI\x00\x05\x00ok", want: "application/x-bsh"},
+		{name: "indirect relative offset fails", data: "RI\x05\x00\x00ok", want: ""},
+		{name: "unsupported", data: "ABCD\x00\x00\xc0\x3fxxxxEF", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var got string
+				for _, sig := range s.list {
+					if sig.matcher.match([]byte(tt.data)) {
+						got = sig.name
+						break
+					}
+				}
+				if got != tt.want {
+					t.Errorf("match = %q, want %q", got, tt.want)
+				}
+			},
+		)
+	}
+
+	want := []int{25, 27}
+	if len(diagnostics) != len(want) {
+		t.Fatalf("ParseMagic() diagnostics = %v, want lines %v", diagnostics, want)
+	}
+	for i, d := range diagnostics {
+		if d.Line != want[i] {
+			t.Errorf("diagnostic %d = %v, want line %d", i, d, want[i])
+		}
+	}
+}
+
+func TestParseMagic_Diagnostics(t *testing.T) {
+	tests := []struct {
+		name  string
+		magic string
+		want  string
+	}{
+		{name: "unsupported type", magic: "0\tdate\t0", want: "unsupported type date"},
+		{name: "unsupported operator", magic: "0\tstring\t>a", want: "unsupported operator >"},
+		{name: "unsupported flag", magic: "0\tstring/W\ta", want: "unsupported flag W"},
+		{name: "unsupported offset", magic: "0\tstring\ta\n>(4.l*2)\tbyte\t1", want: "unsupported offset arithmetic *2"},
+		{name: "invalid regex", magic: "0\tregex\t(", want: "unsupported regex"},
+		{name: "missing test", magic: "0\tstring", want: "expected offset, type and test"},
+		{name: "orphan continuation", magic: ">>0\tbyte\t1", want: "continuation without a parent"},
+		{name: "invalid strength", magic: "0\tstring\ta\n!:mime\ttext/plain\n!:strength\t/0", want: "invalid strength /0"},
+		{name: "unknown media type", magic: "0\tstring\ta\n!:mime\tapplication/x-unknown", want: "not in the registry"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, diagnostics, err := ParseMagic(strings.NewReader(tt.magic))
+				if err != nil {
+					t.Fatalf("ParseMagic() error = %v", err)
+				}
+				if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, tt.want) {
+					t.Errorf("ParseMagic() diagnostics = %v, want %q", diagnostics, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestParseMagic_Strength(t *testing.T) {
+	tests := []struct {
+		strength string
+		want     int
+	}{
+		{strength: "", want: MagicPriority},
+		{strength: "!:strength\t+5\n", want: MagicPriority + 5},
+		{strength: "!:strength\t- 50\n", want: 0},
+		{strength: "!:strength\t*10\n", want: DefaultPriority - 1},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.strength, func(t *testing.T) {
+				s, _, err := ParseMagic(strings.NewReader("0\tstring\tXY\n!:mime\ttext/plain\n" + tt.strength))
+				if err != nil {
+					t.Fatalf("ParseMagic() error = %v", err)
+				}
+				if len(s.list) != 1 || s.list[0].priority != tt.want {
+					t.Errorf("ParseMagic() = %v, want priority %d", s.list, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestLoadMagic(t *testing.T) {
+	defer func(saved []signature) {
+		customSignatures.Lock()
+		customSignatures.list = saved
+		customSignatures.Unlock()
+	}(customSignatures.list)
+
+	magic := "0\tstring\tACMEMAGIC\n!:mime\tapplication/vnd.acme.magic\n" +
+		"0\tbyte\tx\n!:mime\tapplication/x-broad\n!:strength\t-10\n"
+	if _, err := LoadMagic(strings.NewReader(magic)); err != nil {
+		t.Fatalf("LoadMagic() error = %v", err)
+	}
+	tests := []struct {
+		data string
+		want string
+	}{
+		{data: "ACMEMAGIC data", want: "application/vnd.acme.magic"},
+		{data: "%PDF-1.7\n", want: "application/pdf"},
+		{data: "\x89PNG\r\n\x1a\n", want: "image/png"},
+		{data: "\x00\x01\x02", want: "application/x-broad"},
+	}
+	for _, tt := range tests {
+		if got := Detect([]byte(tt.data)); got.Name() != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.data, got.Name(), tt.want)
+		}
+	}
+}
@@ -1,6 +1,18 @@
 package mediatypes
 
 // MediaType represents a media type.
+//
+// A MediaType is an immutable value. Its accessors return copies of any
+// underlying data, so values returned by this package never share mutable
+// state with the registry, and the media type table is never modified after
+// package initialization. It is therefore safe to use MediaType values and
+// the lookup functions from multiple goroutines concurrently, including while
+// translations are added with AddTranslations.
+//
+// Its methods have value receivers, so that they can be called on media types
+// that are not addressable, such as the results of WithParam in a chain of
+// calls, and so that MediaType values, not just pointers, are marshaled as
+// text.
 type MediaType struct {
 	// Name is the media type such as "text/plain" or "application/json".
 	name string
@@ -14,30 +26,46 @@ type MediaType struct {
 
 	// Registered returns true if the media type is registered with IANA.
 	registered bool
+
+	// Params holds the parameters of the media type, such as the charset,
+	// keyed by lower-cased name. Media types in the registry have none.
+	params map[string]string
 }
 
 // String returns the media type as a string.
-func (m *MediaType) String() string {
+func (m MediaType) String() string {
 	return m.name
 }
 
+// MarshalText returns the media type as text, so that it is encoded as a
+// string in formats such as JSON.
+func (m MediaType) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
 // Name returns the media type name.
-func (m *MediaType) Name() string {
+func (m MediaType) Name() string {
 	return m.name
 }
 
 // Format returns the media type format.
-func (m *MediaType) Format() string {
+func (m MediaType) Format() string {
 	return m.format
 }
 
 // Extensions returns a list of file extensions that are associated with this media type.
-func (m *MediaType) Extensions() []string {
-	return m.extensions
+// The returned slice is a copy and may be modified by the caller.
+func (m MediaType) Extensions() []string {
+	if m.extensions == nil {
+		return nil
+	}
+	result := make([]string, len(m.extensions))
+	copy(result, m.extensions)
+	return result
 }
 
 // Registered returns true if the media type is registered with IANA.
-func (m *MediaType) Registered() bool {
+func (m MediaType) Registered() bool {
 	return m.registered
 }
 
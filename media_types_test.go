@@ -49,3 +49,34 @@ func TestByExtension(t *testing.T) {
 		)
 	}
 }
+
+func TestMediaType_Extensions_Copy(t *testing.T) {
+	m := ByExtension("gif")[0]
+	exts := m.Extensions()
+	exts[0] = "x"
+	if got := m.Extensions()[0]; got != "gif" {
+		t.Errorf("Extensions()[0] = %q after modifying a returned slice, want %q", got, "gif")
+	}
+	if got := ByExtension("gif"); len(got) != 1 {
+		t.Errorf("ByExtension(\"gif\") returned %d media types after modifying a returned slice, want 1", len(got))
+	}
+	if got := ByExtension("x"); got != nil {
+		t.Errorf("ByExtension(\"x\") = %v, want nil", got)
+	}
+}
+
+func TestMediaType_Extensions_Registry(t *testing.T) {
+	for _, m := range All() {
+		exts := m.Extensions()
+		for i := range exts {
+			exts[i] = ""
+		}
+	}
+	for _, m := range All() {
+		for _, e := range m.Extensions() {
+			if e == "" {
+				t.Fatalf("%s has an empty extension after modifying returned slices", m.Name())
+			}
+		}
+	}
+}
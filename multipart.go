@@ -0,0 +1,243 @@
+package mediatypes
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"strings"
+)
+
+// boundaryLen is the length of the boundaries returned by NewBoundary.
+const boundaryLen = 40
+
+// boundaryAlphabet holds the characters of the boundaries returned by
+// NewBoundary. RFC 2046 allows more, but some of them must be quoted in the
+// Content-Type header, which not every implementation does.
+const boundaryAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// NewBoundary returns a random boundary for a multipart body. It has 40
+// alphanumeric characters, so it is valid as described by RFC 2046, needs no
+// quoting, and is unlikely to occur in the body.
+func NewBoundary() (string, error) {
+	var (
+		b   = make([]byte, 0, boundaryLen)
+		buf [boundaryLen]byte
+	)
+	for len(b) < boundaryLen {
+		if _, err := io.ReadFull(rand.Reader, buf[:]); err != nil {
+			return "", fmt.Errorf("mediatypes: cannot generate boundary: %v", err)
+		}
+		for _, c := range buf {
+			// Reject values that would bias the choice of character.
+			if int(c) < 256-256%len(boundaryAlphabet) && len(b) < boundaryLen {
+				b = append(b, boundaryAlphabet[int(c)%len(boundaryAlphabet)])
+			}
+		}
+	}
+	return string(b), nil
+}
+
+// ValidateBoundary returns an error if b is not a valid boundary as defined by
+// RFC 2046: 1 to 70 characters from a restricted set, not ending in a space.
+func ValidateBoundary(b string) error {
+	if b == "" || len(b) > 70 {
+		return fmt.Errorf("mediatypes: invalid boundary %q: must have 1 to 70 characters", b)
+	}
+	for i := 0; i < len(b); i++ {
+		if c := b[i]; !isBoundaryChar(c) {
+			return fmt.Errorf("mediatypes: invalid boundary %q: invalid character %q", b, c)
+		}
+	}
+	if b[len(b)-1] == ' ' {
+		return fmt.Errorf("mediatypes: invalid boundary %q: must not end in a space", b)
+	}
+	return nil
+}
+
+// isBoundaryChar returns true if c is a bchar or a space, as defined by RFC
+// 2046.
+func isBoundaryChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("'()+_,-./:=? ", c) >= 0
+}
+
+// NewMultipartReader returns a reader for a multipart body with the given
+// Content-Type, such as `multipart/form-data; boundary="..."`. It returns an
+// error if the media type is not multipart or the boundary is not valid.
+func NewMultipartReader(contentType string, body io.Reader) (*multipart.Reader, error) {
+	m, err := Parse(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(strings.ToLower(m.name), "multipart/") {
+		return nil, fmt.Errorf("mediatypes: %s is not a multipart media type", m.name)
+	}
+	if err := ValidateBoundary(m.Param("boundary")); err != nil {
+		return nil, err
+	}
+	return multipart.NewReader(body, m.Param("boundary")), nil
+}
+
+// PartInfo describes a part of a multipart body.
+type PartInfo struct {
+	// FormName is the name of the form field, for multipart/form-data.
+	FormName string
+
+	// Filename is the file name of the part, without any directory.
+	Filename string
+
+	// Declared is the media type in the Content-Type header of the part. A
+	// part without one is "text/plain", as described by RFC 2046.
+	Declared MediaType
+
+	// FromFilename holds the media types associated with the extension of
+	// the file name, if any.
+	FromFilename []MediaType
+}
+
+// InspectPart returns information about a part from its headers. It does not
+// read the body of the part.
+func InspectPart(p *multipart.Part) (PartInfo, error) {
+	info := PartInfo{FormName: p.FormName(), Filename: p.FileName()}
+	declared := p.Header.Get("Content-Type")
+	if declared == "" {
+		declared = "text/plain"
+	}
+	m, err := Parse(declared)
+	if err != nil {
+		return info, err
+	}
+	info.Declared = m
+	if ext := strings.TrimPrefix(path.Ext(info.Filename), "."); ext != "" {
+		info.FromFilename = ByExtension(strings.ToLower(ext))
+	}
+	return info, nil
+}
+
+// PartPolicy restricts the parts of a multipart body. The zero value allows
+// every part with a well-formed Content-Type.
+type PartPolicy struct {
+	// Allow holds the media types that parts may declare. If it is empty,
+	// every media type is allowed.
+	Allow []Pattern
+
+	// Deny holds the media types that parts must not declare. It takes
+	// precedence over Allow.
+	Deny []Pattern
+
+	// RequireRegistered rejects parts whose declared media type is not in
+	// the registry.
+	RequireRegistered bool
+
+	// RequireMatchingExtension rejects parts whose declared media type,
+	// ignoring parameters, is not one of the media types of the extension of
+	// their file name. Parts without a file name, or with an unknown
+	// extension, are not rejected.
+	RequireMatchingExtension bool
+}
+
+// Check returns an error describing why the policy rejects the part, or nil.
+func (p *PartPolicy) Check(info PartInfo) error {
+	name := info.Declared.name
+	if p.RequireRegistered {
+		if _, ok := ByName(name); !ok {
+			return fmt.Errorf("media type %s is not registered", name)
+		}
+	}
+	for _, pattern := range p.Deny {
+		if pattern.match(name, info.Declared.params) {
+			return fmt.Errorf("media type %s is denied", name)
+		}
+	}
+	if len(p.Allow) > 0 {
+		allowed := false
+		for _, pattern := range p.Allow {
+			if pattern.match(name, info.Declared.params) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("media type %s is not allowed", name)
+		}
+	}
+	if p.RequireMatchingExtension && len(info.FromFilename) > 0 {
+		// A more general or more specific type is not enough: a.html
+		// declared as text/plain, or anything declared as
+		// application/octet-stream, would bypass the check.
+		consistent := false
+		for _, m := range info.FromFilename {
+			if info.Declared.EqualIgnoringParams(m) {
+				consistent = true
+				break
+			}
+		}
+		if !consistent {
+			return fmt.Errorf("media type %s does not match file name %q", name, info.Filename)
+		}
+	}
+	return nil
+}
+
+// PartError describes a part of a multipart body that was rejected.
+type PartError struct {
+	// Index is the position of the part in the body, starting at 0.
+	Index int
+
+	// Info describes the part. Declared is empty if the Content-Type of
+	// the part is malformed.
+	Info PartInfo
+
+	// Err is the reason the part was rejected.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *PartError) Error() string {
+	return fmt.Sprintf("mediatypes: part %d (%q): %v", e.Index, e.Info.FormName, e.Err)
+}
+
+// Unwrap returns the reason the part was rejected.
+func (e *PartError) Unwrap() error {
+	return e.Err
+}
+
+// WalkParts reads the parts of a multipart body, checks each against the
+// policy, and calls fn with each part that passes. The body of each part is
+// not read beforehand, so fn can stream it; WalkParts moves on to the next
+// part when fn returns. If a part is rejected, WalkParts returns a
+// *PartError; if fn returns an error, WalkParts returns it. A nil policy
+// allows every part with a well-formed Content-Type.
+func WalkParts(r *multipart.Reader, policy *PartPolicy, fn func(*multipart.Part, PartInfo) error) error {
+	if policy == nil {
+		policy = &PartPolicy{}
+	}
+	for i := 0; ; i++ {
+		p, err := r.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		info, err := InspectPart(p)
+		if err == nil {
+			err = policy.Check(info)
+		}
+		if err != nil {
+			_ = p.Close()
+			return &PartError{Index: i, Info: info, Err: err}
+		}
+		err = fn(p, info)
+		_ = p.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package mediatypes
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestNewBoundary(t *testing.T) {
+	a, err := NewBoundary()
+	if err != nil {
+		t.Fatalf("NewBoundary() error = %v", err)
+	}
+	b, err := NewBoundary()
+	if err != nil {
+		t.Fatalf("NewBoundary() error = %v", err)
+	}
+	if a == b {
+		t.Errorf("NewBoundary() returned %q twice", a)
+	}
+	if err := ValidateBoundary(a); err != nil {
+		t.Errorf("ValidateBoundary(%q) error = %v", a, err)
+	}
+	if !isToken(a) {
+		t.Errorf("NewBoundary() = %q, which needs quoting", a)
+	}
+}
+
+func TestValidateBoundary(t *testing.T) {
+	tests := []struct {
+		name     string
+		boundary string
+		wantErr  bool
+	}{
+		{name: "simple", boundary: "abc123"},
+		{name: "specials", boundary: "a'()+_,-./:=? b"},
+		{name: "longest", boundary: strings.Repeat("a", 70)},
+		{name: "empty", boundary: "", wantErr: true},
+		{name: "too long", boundary: strings.Repeat("a", 71), wantErr: true},
+		{name: "trailing space", boundary: "abc ", wantErr: true},
+		{name: "invalid character", boundary: "a;b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if err := ValidateBoundary(tt.boundary); (err != nil) != tt.wantErr {
+					t.Errorf("ValidateBoundary(%q) error = %v, wantErr %v", tt.boundary, err, tt.wantErr)
+				}
+			},
+		)
+	}
+}
+
+// testPart is a part of a test multipart body.
+type testPart struct {
+	formName    string
+	filename    string
+	contentType string
+	body        string
+}
+
+// makeMultipart returns a multipart/form-data body and its Content-Type.
+func makeMultipart(t *testing.T, parts ...testPart) ([]byte, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		h := make(textproto.MIMEHeader)
+		disposition := `form-data; name="` + p.formName + `"`
+		if p.filename != "" {
+			disposition += `; filename="` + p.filename + `"`
+		}
+		h.Set("Content-Disposition", disposition)
+		if p.contentType != "" {
+			h.Set("Content-Type", p.contentType)
+		}
+		pw, err := w.CreatePart(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pw.Write([]byte(p.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), w.FormDataContentType()
+}
+
+func TestWalkParts(t *testing.T) {
+	body, contentType := makeMultipart(
+		t,
+		testPart{formName: "title", body: "Report"},
+		testPart{formName: "file", filename: "report.pdf", contentType: "application/pdf", body: "%PDF-1.7"},
+		testPart{formName: "data", filename: "data.json", contentType: "application/json; charset=utf-8", body: "{}"},
+	)
+	r, err := NewMultipartReader(contentType, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewMultipartReader() error = %v", err)
+	}
+
+	var got []string
+	err = WalkParts(
+		r, nil, func(p *multipart.Part, info PartInfo) error {
+			data, err := ioutil.ReadAll(p)
+			if err != nil {
+				return err
+			}
+			var fromFilename string
+			if len(info.FromFilename) > 0 {
+				fromFilename = info.FromFilename[0].Name()
+			}
+			got = append(got, info.FormName+" "+info.Declared.ContentType()+" "+fromFilename+" "+string(data))
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("WalkParts() error = %v", err)
+	}
+	want := []string{
+		"title text/plain  Report",
+		"file application/pdf application/pdf %PDF-1.7",
+		"data application/json; charset=utf-8 application/json {}",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("WalkParts() visited\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWalkParts_Policy(t *testing.T) {
+	tests := []struct {
+		name      string
+		part      testPart
+		policy    PartPolicy
+		wantError string
+	}{
+		{name: "allowed", part: testPart{formName: "f", filename: "a.png", contentType: "image/png"}, policy: PartPolicy{Allow: []Pattern{MustParsePattern("image/*")}}},
+		{name: "not allowed", part: testPart{formName: "f", filename: "a.pdf", contentType: "application/pdf"}, policy: PartPolicy{Allow: []Pattern{MustParsePattern("image/*")}}, wantError: "not allowed"},
+		{name: "denied", part: testPart{formName: "f", contentType: "image/svg+xml"}, policy: PartPolicy{Allow: []Pattern{MustParsePattern("image/*")}, Deny: []Pattern{MustParsePattern("image/svg+xml")}}, wantError: "denied"},
+		{name: "unregistered", part: testPart{formName: "f", contentType: "application/x-acme"}, policy: PartPolicy{RequireRegistered: true}, wantError: "not registered"},
+		{name: "malformed", part: testPart{formName: "f", contentType: "image/"}, wantError: "invalid media type"},
+		{name: "mismatched extension", part: testPart{formName: "f", filename: "a.exe", contentType: "image/png"}, policy: PartPolicy{RequireMatchingExtension: true}, wantError: "does not match"},
+		{name: "matching extension with parameters", part: testPart{formName: "f", filename: "a.html", contentType: "text/html; charset=utf-8"}, policy: PartPolicy{RequireMatchingExtension: true}},
+		{name: "generic declared type", part: testPart{formName: "f", filename: "a.docx", contentType: "application/zip"}, policy: PartPolicy{RequireMatchingExtension: true}, wantError: "does not match"},
+		{name: "html declared as text", part: testPart{formName: "f", filename: "x.html", contentType: "text/plain"}, policy: PartPolicy{RequireMatchingExtension: true}, wantError: "does not match"},
+		{name: "html declared as binary", part: testPart{formName: "f", filename: "x.html", contentType: "application/octet-stream"}, policy: PartPolicy{RequireMatchingExtension: true}, wantError: "does not match"},
+		{name: "unknown extension", part: testPart{formName: "f", filename: "a.acme", contentType: "image/png"}, policy: PartPolicy{RequireMatchingExtension: true}},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				body, contentType := makeMultipart(t, tt.part)
+				r, err := NewMultipartReader(contentType, bytes.NewReader(body))
+				if err != nil {
+					t.Fatalf("NewMultipartReader() error = %v", err)
+				}
+				policy := tt.policy
+				err = WalkParts(
+					r, &policy, func(*multipart.Part, PartInfo) error {
+						return nil
+					},
+				)
+				if tt.wantError == "" {
+					if err != nil {
+						t.Errorf("WalkParts() error = %v", err)
+					}
+					return
+				}
+				var partErr *PartError
+				if !errors.As(err, &partErr) || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("WalkParts() error = %v, want a *PartError containing %q", err, tt.wantError)
+				}
+			},
+		)
+	}
+}
+
+func TestNewMultipartReader_Invalid(t *testing.T) {
+	for _, contentType := range []string{"text/plain", "multipart/mixed", "multipart/mixed; boundary=\"abc \"", "multipart/"} {
+		if _, err := NewMultipartReader(contentType, strings.NewReader("")); err == nil {
+			t.Errorf("NewMultipartReader(%q) error = nil, want an error", contentType)
+		}
+	}
+}
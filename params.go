@@ -0,0 +1,68 @@
+package mediatypes
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// Parse parses a media type in the form used by the Content-Type header, such
+// as "text/html; charset=utf-8". The result is the registry entry with the
+// given parameters, or a media type with just the lower-cased name if the
+// registry does not contain it. Parameter names are lower-cased and values
+// encoded as described by RFC 2231 are decoded.
+func Parse(s string) (MediaType, error) {
+	name, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return MediaType{}, fmt.Errorf("mediatypes: invalid media type %q: %v", s, err)
+	}
+	m := lookup(name)
+	if len(params) > 0 {
+		m.params = params
+	}
+	return m, nil
+}
+
+// Params returns the parameters of the media type, such as the charset,
+// keyed by lower-cased name. Media types in the registry have no parameters;
+// those returned by detection may. The returned map is a copy and may be
+// modified by the caller.
+func (m MediaType) Params() map[string]string {
+	if len(m.params) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(m.params))
+	for k, v := range m.params {
+		result[k] = v
+	}
+	return result
+}
+
+// Param returns the value of the named parameter, or an empty string if the
+// media type does not have it. Parameter names are case-insensitive.
+func (m MediaType) Param(name string) string {
+	return m.params[strings.ToLower(name)]
+}
+
+// WithParam returns a copy of the media type with the named parameter set to
+// value. Parameter names are case-insensitive. The media type itself is not
+// modified, so registry entries can be used as templates, and calls can be
+// chained:
+//
+//	m, _ := mediatypes.ByName("text/plain")
+//	header := m.WithCharset("utf-8").WithParam("format", "flowed").ContentType()
+func (m MediaType) WithParam(name, value string) MediaType {
+	result := m
+	result.params = make(map[string]string, len(m.params)+1)
+	for k, v := range m.params {
+		result.params[k] = v
+	}
+	result.params[strings.ToLower(name)] = value
+	return result
+}
+
+// WithCharset returns a copy of the media type with the charset parameter set
+// to charset.
+func (m MediaType) WithCharset(charset string) MediaType {
+	return m.WithParam("charset", charset)
+}
@@ -0,0 +1,56 @@
+package mediatypes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMediaType_Params(t *testing.T) {
+	m, _ := ByName("text/plain")
+	if got := m.Params(); got != nil {
+		t.Errorf("Params() = %v, want nil", got)
+	}
+
+	with := m.WithParam("Charset", "utf-8")
+	if got := with.Param("CHARSET"); got != "utf-8" {
+		t.Errorf("Param(\"CHARSET\") = %q, want %q", got, "utf-8")
+	}
+	if got := m.Param("charset"); got != "" {
+		t.Errorf("WithParam() modified the original: Param(\"charset\") = %q", got)
+	}
+
+	params := with.Params()
+	params["charset"] = "x"
+	if got := with.Param("charset"); got != "utf-8" {
+		t.Errorf("modifying Params() modified the media type: Param(\"charset\") = %q", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          string
+		want       string
+		wantParams map[string]string
+		wantErr    bool
+	}{
+		{name: "registered", s: "Text/HTML; Charset=UTF-8", want: "text/html", wantParams: map[string]string{"charset": "UTF-8"}},
+		{name: "no parameters", s: "application/3gpphal+json", want: "application/3gppHal+json"},
+		{name: "extended value", s: "text/plain; title*=UTF-8''%E2%82%AC", want: "text/plain", wantParams: map[string]string{"title": "€"}},
+		{name: "unregistered", s: "Application/X-Acme", want: "application/x-acme"},
+		{name: "invalid", s: "text/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := Parse(tt.s)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+				}
+				if got.Name() != tt.want || !reflect.DeepEqual(got.Params(), tt.wantParams) {
+					t.Errorf("Parse(%q) = %q %v, want %q %v", tt.s, got.Name(), got.Params(), tt.want, tt.wantParams)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,154 @@
+package mediatypes
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// Pattern represents a media range such as "image/*", "*/*",
+// "application/*+json", "application/vnd.acme.*" or
+// "text/plain; charset=utf-8".
+//
+// The top-level type is either a literal or "*". The subtype may contain at
+// most one "*", which matches any run of characters, so "*+json" matches
+// every JSON-structured subtype and "vnd.acme.*" matches the acme vendor
+// tree. Parameters are constraints: a media type matches only if it carries
+// each of them with an equal value.
+type Pattern struct {
+	// typ is the lower-cased top-level type, or "*".
+	typ string
+
+	// prefix and suffix are the lower-cased parts of the subtype before and
+	// after the wildcard. If the subtype has no wildcard, prefix holds the
+	// whole subtype and wildcard is false.
+	prefix   string
+	suffix   string
+	wildcard bool
+
+	// params are the parameter constraints, keyed by lower-cased name.
+	params map[string]string
+
+	// raw is the pattern as it was given to ParsePattern.
+	raw string
+}
+
+// ParsePattern parses a media range such as "image/*" or
+// "application/*+json; charset=utf-8".
+func ParsePattern(s string) (Pattern, error) {
+	p := Pattern{raw: s}
+
+	value := s
+	if i := strings.IndexByte(s, ';'); i >= 0 {
+		value = s[:i]
+		_, params, err := mime.ParseMediaType("x/x" + s[i:])
+		if err != nil {
+			return Pattern{}, fmt.Errorf("mediatypes: invalid pattern %q: %v", s, err)
+		}
+		p.params = params
+	}
+
+	value = strings.ToLower(strings.TrimSpace(value))
+	slash := strings.IndexByte(value, '/')
+	if slash <= 0 || slash == len(value)-1 {
+		return Pattern{}, fmt.Errorf("mediatypes: invalid pattern %q: expected type/subtype", s)
+	}
+	p.typ = value[:slash]
+	subtype := value[slash+1:]
+
+	if strings.IndexByte(p.typ, '*') >= 0 && p.typ != "*" {
+		return Pattern{}, fmt.Errorf("mediatypes: invalid pattern %q: wildcard must be the whole type", s)
+	}
+	if p.typ == "*" && subtype != "*" {
+		return Pattern{}, fmt.Errorf("mediatypes: invalid pattern %q: type wildcard requires subtype wildcard", s)
+	}
+	switch strings.Count(subtype, "*") {
+	case 0:
+		p.prefix = subtype
+	case 1:
+		i := strings.IndexByte(subtype, '*')
+		p.prefix = subtype[:i]
+		p.suffix = subtype[i+1:]
+		p.wildcard = true
+	default:
+		return Pattern{}, fmt.Errorf("mediatypes: invalid pattern %q: more than one wildcard in subtype", s)
+	}
+	return p, nil
+}
+
+// MustParsePattern is like ParsePattern but panics if the pattern cannot be
+// parsed. It simplifies safe initialization of global variables.
+func MustParsePattern(s string) Pattern {
+	p, err := ParsePattern(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the pattern as it was given to ParsePattern.
+func (p Pattern) String() string {
+	return p.raw
+}
+
+// Match returns true if the media type, including its parameters, matches the
+// pattern.
+func (p Pattern) Match(m MediaType) bool {
+	return p.match(m.name, m.params)
+}
+
+// MatchString returns true if the media type, given in the form used by the
+// Content-Type header, matches the pattern. Malformed values never match.
+func (p Pattern) MatchString(s string) bool {
+	name, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return false
+	}
+	return p.match(name, params)
+}
+
+// match reports whether name and params satisfy the pattern.
+func (p Pattern) match(name string, params map[string]string) bool {
+	name = strings.ToLower(name)
+	slash := strings.IndexByte(name, '/')
+	if slash < 0 {
+		return false
+	}
+	typ, subtype := name[:slash], name[slash+1:]
+
+	if p.typ != "*" && p.typ != typ {
+		return false
+	}
+	if p.wildcard {
+		if len(subtype) < len(p.prefix)+len(p.suffix) ||
+			!strings.HasPrefix(subtype, p.prefix) ||
+			!strings.HasSuffix(subtype, p.suffix) {
+			return false
+		}
+	} else if p.prefix != subtype {
+		return false
+	}
+
+	for k, want := range p.params {
+		got, ok := params[k]
+		if !ok || !equalParamValues(k, got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the media types that match any of the given patterns, in
+// registry order.
+func Filter(patterns ...Pattern) []MediaType {
+	return collect(
+		func(m MediaType) bool {
+			for _, p := range patterns {
+				if p.Match(m) {
+					return true
+				}
+			}
+			return false
+		},
+	)
+}
@@ -0,0 +1,124 @@
+package mediatypes
+
+import (
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{name: "any", pattern: "*/*"},
+		{name: "top-level wildcard", pattern: "image/*"},
+		{name: "suffix wildcard", pattern: "application/*+json"},
+		{name: "tree prefix", pattern: "application/vnd.acme.*"},
+		{name: "parameters", pattern: "text/plain; charset=utf-8"},
+		{name: "empty", pattern: "", wantErr: true},
+		{name: "missing subtype", pattern: "image/", wantErr: true},
+		{name: "partial type wildcard", pattern: "im*/png", wantErr: true},
+		{name: "type wildcard with subtype", pattern: "*/png", wantErr: true},
+		{name: "two wildcards", pattern: "application/*.*", wantErr: true},
+		{name: "bad parameters", pattern: "text/plain; charset", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, err := ParsePattern(tt.pattern)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("ParsePattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+				}
+			},
+		)
+	}
+}
+
+func TestPattern_MatchString(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{pattern: "*/*", value: "image/png", want: true},
+		{pattern: "image/*", value: "image/png", want: true},
+		{pattern: "image/*", value: "text/plain", want: false},
+		{pattern: "application/*+json", value: "application/geo+json", want: true},
+		{pattern: "application/*+json", value: "application/json", want: false},
+		{pattern: "application/vnd.acme.*", value: "application/vnd.acme.telemetry", want: true},
+		{pattern: "application/vnd.acme.*", value: "application/vnd.acmecorp", want: false},
+		{pattern: "TEXT/HTML", value: "text/html", want: true},
+		{pattern: "text/plain; charset=utf-8", value: "text/plain; charset=UTF-8", want: true},
+		{pattern: "text/plain; charset=utf-8", value: "text/plain", want: false},
+		{pattern: "text/plain; format=flowed", value: "text/plain; format=Flowed", want: false},
+		{pattern: "*/*", value: "not a media type", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.pattern+" "+tt.value, func(t *testing.T) {
+				p := MustParsePattern(tt.pattern)
+				if got := p.MatchString(tt.value); got != tt.want {
+					t.Errorf("MatchString(%q) = %v, want %v", tt.value, got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestPattern_Match(t *testing.T) {
+	plain, _ := ByName("text/plain")
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{pattern: "text/*", value: "text/plain", want: true},
+		{pattern: "text/plain; charset=utf-8", value: "text/plain; charset=UTF-8", want: true},
+		{pattern: "text/plain; charset=utf-8", value: "text/plain; charset=iso-8859-1", want: false},
+		{pattern: "text/plain; charset=utf-8", value: "text/plain", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.pattern+" "+tt.value, func(t *testing.T) {
+				p := MustParsePattern(tt.pattern)
+				m, err := Parse(tt.value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := p.Match(m); got != tt.want {
+					t.Errorf("Match(%q) = %v, want %v", tt.value, got, tt.want)
+				}
+				if got := p.MatchString(tt.value); got != tt.want {
+					t.Errorf("MatchString(%q) = %v, want %v", tt.value, got, tt.want)
+				}
+			},
+		)
+	}
+
+	p := MustParsePattern("text/plain; charset=utf-8")
+	if !p.Match(plain.WithCharset("utf-8")) {
+		t.Errorf("Match(%q) = false, want true", plain.WithCharset("utf-8").ContentType())
+	}
+	if p.Match(plain) {
+		t.Errorf("Match(%q) = true, want false", plain.ContentType())
+	}
+}
+
+func TestFilter(t *testing.T) {
+	got := Filter(MustParsePattern("image/gif"), MustParsePattern("application/*+zip"))
+	if len(got) < 2 {
+		t.Fatalf("Filter() returned %d media types, want at least 2", len(got))
+	}
+	seen := map[string]bool{}
+	for _, m := range got {
+		seen[m.Name()] = true
+	}
+	for _, name := range []string{"image/gif", "application/epub+zip"} {
+		if !seen[name] {
+			t.Errorf("Filter() is missing %s", name)
+		}
+	}
+	if got := Filter(MustParsePattern("text/plain; charset=utf-8")); got != nil {
+		t.Errorf("Filter() with parameter constraints = %v, want nil", got)
+	}
+}
@@ -0,0 +1,218 @@
+package mediatypes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Default limits of DetectReaderAt.
+const (
+	// DefaultSniffSize is the number of bytes read from the start of the
+	// content.
+	DefaultSniffSize = sniffLen
+
+	// DefaultMaxBytes is the largest number of bytes read in total.
+	DefaultMaxBytes = 1 << 20
+
+	// DefaultMaxSeeks is the largest number of reads at distinct offsets.
+	DefaultMaxSeeks = 64
+)
+
+// errLimitExceeded is returned by a limitedReaderAt once a limit is reached.
+var errLimitExceeded = errors.New("mediatypes: detection limit exceeded")
+
+// detectOptions holds the options of DetectReaderAt.
+type detectOptions struct {
+	sniffSize int
+	maxBytes  int64
+	maxSeeks  int
+}
+
+// DetectOption configures DetectReaderAt.
+type DetectOption func(*detectOptions)
+
+// WithSniffSize sets the number of bytes read from the start of the content.
+// Formats such as ZIP containers are recognized more often from a larger
+// prefix; most others need only the default of 512 bytes.
+func WithSniffSize(n int) DetectOption {
+	return func(o *detectOptions) {
+		o.sniffSize = n
+	}
+}
+
+// WithMaxBytes sets the largest number of bytes read in total, including
+// those read from the end of the content.
+func WithMaxBytes(n int64) DetectOption {
+	return func(o *detectOptions) {
+		o.maxBytes = n
+	}
+}
+
+// WithMaxSeeks sets the largest number of reads, each of which may be at a
+// different offset.
+func WithMaxSeeks(n int) DetectOption {
+	return func(o *detectOptions) {
+		o.maxSeeks = n
+	}
+}
+
+// Result is the result of DetectReaderAt.
+type Result struct {
+	// MediaType is the detected media type.
+	MediaType MediaType
+
+	// BytesRead is the number of bytes read.
+	BytesRead int64
+
+	// Seeks is the number of reads.
+	Seeks int
+
+	// Truncated is true if a limit or the context cut detection short, so
+	// that MediaType may be less specific than it could have been.
+	Truncated bool
+}
+
+// DetectReaderAt returns the media type of content of the given size. Unlike
+// Detect, it can look beyond the first bytes: it reads the central directory
+// of ZIP archives to recognize container formats, and the end of the content
+// for ID3v1 tags and PDF trailers. Reads are bounded by the options, and
+// detection stops when the context is done; either is reported in the
+// result rather than as an error, unless the start of the content could not
+// be read at all, or the size or one of the limits is negative. A limit of
+// zero allows no reads, so non-empty content is reported as truncated
+// application/octet-stream.
+func DetectReaderAt(ctx context.Context, r io.ReaderAt, size int64, opts ...DetectOption) (Result, error) {
+	o := detectOptions{
+		sniffSize: DefaultSniffSize,
+		maxBytes:  DefaultMaxBytes,
+		maxSeeks:  DefaultMaxSeeks,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	switch {
+	case size < 0:
+		return Result{}, fmt.Errorf("mediatypes: negative size %d", size)
+	case o.sniffSize < 0:
+		return Result{}, fmt.Errorf("mediatypes: negative sniff size %d", o.sniffSize)
+	case o.maxBytes < 0:
+		return Result{}, fmt.Errorf("mediatypes: negative byte limit %d", o.maxBytes)
+	case o.maxSeeks < 0:
+		return Result{}, fmt.Errorf("mediatypes: negative seek limit %d", o.maxSeeks)
+	}
+	lr := &limitedReaderAt{ctx: ctx, r: r, bytesLeft: o.maxBytes, seeksLeft: o.maxSeeks}
+	result := func(m MediaType, truncated bool) Result {
+		return Result{
+			MediaType: m,
+			BytesRead: o.maxBytes - lr.bytesLeft,
+			Seeks:     o.maxSeeks - lr.seeksLeft,
+			Truncated: truncated || lr.limited,
+		}
+	}
+
+	if size > 0 && (o.maxBytes == 0 || o.maxSeeks == 0) {
+		return result(lookup("application/octet-stream"), true), nil
+	}
+
+	headSize := int64(o.sniffSize)
+	if headSize > size {
+		headSize = size
+	}
+	if headSize > lr.bytesLeft {
+		headSize = lr.bytesLeft
+		lr.limited = true
+	}
+	head := make([]byte, headSize)
+	n, err := lr.ReadAt(head, 0)
+	if err != nil && err != io.EOF && n == 0 {
+		return Result{}, err
+	}
+	head = head[:n]
+	m := detect(head, int64(len(head)) == size)
+
+	switch strings.ToLower(m.name) {
+	case "application/zip":
+		if c, err := DetectContainer(lr, size); err == nil {
+			m = c
+		}
+	case "application/octet-stream", "text/plain":
+		if t, ok := detectTail(lr, head, size, m.name == "application/octet-stream"); ok {
+			m = t
+		}
+	}
+	return result(m, ctx.Err() != nil), nil
+}
+
+// detectTail recognizes content from its end: binary MP3 audio without an
+// ID3v2 header from its ID3v1 tag, and PDF documents with leading junk from
+// their header near the start and their trailer. The ID3v1 tag is only
+// trusted if the start of the content is not a known format, since any file
+// can end in "TAG".
+func detectTail(r io.ReaderAt, head []byte, size int64, binary bool) (MediaType, bool) {
+	if _, known := scanStart(head); binary && !known && size >= 128 {
+		tag := make([]byte, 3)
+		if _, err := r.ReadAt(tag, size-128); err == nil && string(tag) == "TAG" {
+			return ByName("audio/mpeg")
+		}
+	}
+
+	// PDF readers accept the header anywhere in the first 1024 bytes, but
+	// only the sniffed bytes are searched, which are fewer unless the sniff
+	// size was raised with WithSniffSize.
+	prefix := head
+	if len(prefix) > 1024 {
+		prefix = prefix[:1024]
+	}
+	if bytes.Contains(prefix, []byte("%PDF-")) {
+		tail := int64(1024)
+		if tail > size {
+			tail = size
+		}
+		trailer := make([]byte, tail)
+		if _, err := r.ReadAt(trailer, size-tail); err == nil && bytes.Contains(trailer, []byte("%%EOF")) {
+			return ByName("application/pdf")
+		}
+	}
+	return MediaType{}, false
+}
+
+// limitedReaderAt reads from an io.ReaderAt until a limit is reached or the
+// context is done.
+type limitedReaderAt struct {
+	ctx       context.Context
+	r         io.ReaderAt
+	bytesLeft int64
+	seeksLeft int
+
+	// limited is set once a read was refused or shortened.
+	limited bool
+}
+
+// ReadAt implements io.ReaderAt.
+func (l *limitedReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if err := l.ctx.Err(); err != nil {
+		l.limited = true
+		return 0, err
+	}
+	if l.seeksLeft <= 0 || l.bytesLeft <= 0 {
+		l.limited = true
+		return 0, errLimitExceeded
+	}
+	short := false
+	if int64(len(p)) > l.bytesLeft {
+		p = p[:l.bytesLeft]
+		short = true
+	}
+	l.seeksLeft--
+	n, err := l.r.ReadAt(p, off)
+	l.bytesLeft -= int64(n)
+	if short && err == nil {
+		l.limited = true
+		err = errLimitExceeded
+	}
+	return n, err
+}
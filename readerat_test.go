@@ -0,0 +1,97 @@
+package mediatypes
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDetectReaderAt(t *testing.T) {
+	docx := makeZip(
+		t,
+		contentTypes("application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"),
+		zipEntry{name: "word/document.xml", body: strings.Repeat("<w:p/>", 1000), method: 8},
+	)
+	mp3 := append(append([]byte{0x00, 0x00, 0x00, 0x00}, make([]byte, 1000)...), append([]byte("TAG"), make([]byte, 125)...)...)
+	exe := append([]byte("MZ"+strings.Repeat("\x00", 58)+"\x40\x00\x00\x00PE\x00\x00"), mp3...)
+	pdf := []byte("garbage\n%PDF-1.4\n1 0 obj\n<<>>\nendobj\n" + strings.Repeat("x", 2000) + "\ntrailer\n%%EOF\n")
+
+	tests := []struct {
+		name          string
+		data          []byte
+		opts          []DetectOption
+		want          string
+		wantTruncated bool
+	}{
+		{name: "zip central directory", data: docx, want: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{name: "seek limit", data: docx, opts: []DetectOption{WithMaxSeeks(1)}, want: "application/zip", wantTruncated: true},
+		{name: "byte limit", data: docx, opts: []DetectOption{WithMaxBytes(600)}, want: "application/zip", wantTruncated: true},
+		{name: "small sniff size", data: []byte("GIF89a\x01\x00"), opts: []DetectOption{WithSniffSize(4)}, want: "text/plain"},
+		{name: "id3v1", data: mp3, want: "audio/mpeg"},
+		{name: "id3v1 after executable", data: exe, want: "application/octet-stream"},
+		{name: "zero byte limit", data: docx, opts: []DetectOption{WithMaxBytes(0)}, want: "application/octet-stream", wantTruncated: true},
+		{name: "zero seek limit", data: docx, opts: []DetectOption{WithMaxSeeks(0)}, want: "application/octet-stream", wantTruncated: true},
+		{name: "pdf trailer", data: pdf, want: "application/pdf"},
+		{name: "pdf without trailer", data: pdf[:100], want: "text/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := DetectReaderAt(context.Background(), bytes.NewReader(tt.data), int64(len(tt.data)), tt.opts...)
+				if err != nil {
+					t.Fatalf("DetectReaderAt() error = %v", err)
+				}
+				if got.MediaType.Name() != tt.want || got.Truncated != tt.wantTruncated {
+					t.Errorf(
+						"DetectReaderAt() = %s, truncated %v, want %s, truncated %v",
+						got.MediaType.Name(), got.Truncated, tt.want, tt.wantTruncated,
+					)
+				}
+			},
+		)
+	}
+}
+
+func TestDetectReaderAt_Invalid(t *testing.T) {
+	data := []byte("GIF89a")
+	tests := []struct {
+		name string
+		size int64
+		opts []DetectOption
+	}{
+		{name: "negative size", size: -1},
+		{name: "negative sniff size", size: int64(len(data)), opts: []DetectOption{WithSniffSize(-1)}},
+		{name: "negative byte limit", size: int64(len(data)), opts: []DetectOption{WithMaxBytes(-1)}},
+		{name: "negative seek limit", size: int64(len(data)), opts: []DetectOption{WithMaxSeeks(-1)}},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if _, err := DetectReaderAt(context.Background(), bytes.NewReader(data), tt.size, tt.opts...); err == nil {
+					t.Error("DetectReaderAt() error = nil, want an error")
+				}
+			},
+		)
+	}
+}
+
+func TestDetectReaderAt_Limits(t *testing.T) {
+	data := makeZip(t, zipEntry{name: "a.txt", body: strings.Repeat("a", 100000), method: 8})
+	got, err := DetectReaderAt(context.Background(), bytes.NewReader(data), int64(len(data)), WithMaxBytes(1000), WithMaxSeeks(3))
+	if err != nil {
+		t.Fatalf("DetectReaderAt() error = %v", err)
+	}
+	if got.BytesRead > 1000 || got.Seeks > 3 {
+		t.Errorf("DetectReaderAt() read %d bytes in %d seeks, want at most 1000 in 3", got.BytesRead, got.Seeks)
+	}
+}
+
+func TestDetectReaderAt_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	data := []byte("GIF89a")
+	if _, err := DetectReaderAt(ctx, bytes.NewReader(data), int64(len(data))); err != context.Canceled {
+		t.Errorf("DetectReaderAt() error = %v, want %v", err, context.Canceled)
+	}
+}
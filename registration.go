@@ -0,0 +1,124 @@
+package mediatypes
+
+// Usage is the intended usage of a media type, as given in its IANA
+// registration template.
+type Usage int
+
+const (
+	// UsageUnspecified means the intended usage is not known.
+	UsageUnspecified Usage = iota
+
+	// UsageCommon means the media type is intended for general use.
+	UsageCommon
+
+	// UsageLimited means the media type is intended for limited use.
+	UsageLimited
+
+	// UsageObsolete means the media type should no longer be used.
+	UsageObsolete
+)
+
+// String returns the intended usage as it appears in IANA registration
+// templates, such as "COMMON".
+func (u Usage) String() string {
+	switch u {
+	case UsageCommon:
+		return "COMMON"
+	case UsageLimited:
+		return "LIMITED USE"
+	case UsageObsolete:
+		return "OBSOLETE"
+	}
+	return ""
+}
+
+// Registration describes the IANA registration of a media type. Only
+// Registered and Template are known for every media type. References and
+// IntendedUsage are only set for a small, hand-curated set of common media
+// types, so an empty References or an unspecified IntendedUsage does not mean
+// that IANA says so.
+type Registration struct {
+	// Registered is true if the media type is registered with IANA.
+	Registered bool
+
+	// References lists the specifications that define the media type, such
+	// as "RFC 8259".
+	References []string
+
+	// Template is the URL of the IANA registration template, or empty if the
+	// media type is not registered.
+	Template string
+
+	// IntendedUsage is the intended usage of the media type, or
+	// UsageUnspecified if it is not known.
+	IntendedUsage Usage
+}
+
+// registration holds the registration metadata that is not part of the
+// media type table itself.
+type registration struct {
+	references    []string
+	intendedUsage Usage
+}
+
+// templateBaseURL is the URL under which IANA publishes registration
+// templates.
+const templateBaseURL = "https://www.iana.org/assignments/media-types/"
+
+// Registration returns the IANA registration metadata of the media type. The
+// metadata is partial, as described for Registration.
+func (m MediaType) Registration() Registration {
+	r := Registration{Registered: m.registered}
+	if m.registered {
+		r.Template = templateBaseURL + m.name
+	}
+	if reg, ok := registrations[m.name]; ok {
+		r.References = append([]string(nil), reg.references...)
+		r.IntendedUsage = reg.intendedUsage
+	}
+	return r
+}
+
+// registrations holds the known registration metadata, keyed by media type
+// name. It is curated by hand from the "Reference" column of the IANA media
+// types registry, and the intended usage is only set for the entries whose
+// registration template was checked.
+var registrations = map[string]registration{
+	"application/atom+xml":         {references: []string{"RFC 4287", "RFC 5023"}, intendedUsage: UsageCommon},
+	"application/ecmascript":       {references: []string{"RFC 9239"}, intendedUsage: UsageObsolete},
+	"application/geo+json":         {references: []string{"RFC 7946"}, intendedUsage: UsageCommon},
+	"application/gzip":             {references: []string{"RFC 6713"}, intendedUsage: UsageCommon},
+	"application/javascript":       {references: []string{"RFC 9239"}, intendedUsage: UsageObsolete},
+	"application/json":             {references: []string{"RFC 8259"}, intendedUsage: UsageCommon},
+	"application/json-patch+json":  {references: []string{"RFC 6902"}, intendedUsage: UsageCommon},
+	"application/merge-patch+json": {references: []string{"RFC 7396"}, intendedUsage: UsageCommon},
+	"application/octet-stream":     {references: []string{"RFC 2045", "RFC 2046"}},
+	"application/ogg":              {references: []string{"RFC 5334", "RFC 7845"}},
+	"application/pdf":              {references: []string{"RFC 8118"}, intendedUsage: UsageCommon},
+	"application/postscript":       {references: []string{"RFC 2045", "RFC 2046"}},
+	"application/problem+json":     {references: []string{"RFC 7807"}, intendedUsage: UsageCommon},
+	"application/soap+xml":         {references: []string{"RFC 3902"}},
+	"application/sql":              {references: []string{"RFC 6922"}},
+	"application/vnd.geo+json":     {references: []string{"RFC 7946"}, intendedUsage: UsageObsolete},
+	"application/xml":              {references: []string{"RFC 7303"}, intendedUsage: UsageCommon},
+	"audio/mpeg":                   {references: []string{"RFC 3003"}},
+	"audio/ogg":                    {references: []string{"RFC 5334", "RFC 7845"}},
+	"font/otf":                     {references: []string{"RFC 8081"}, intendedUsage: UsageCommon},
+	"font/ttf":                     {references: []string{"RFC 8081"}, intendedUsage: UsageCommon},
+	"font/woff":                    {references: []string{"RFC 8081"}, intendedUsage: UsageCommon},
+	"font/woff2":                   {references: []string{"RFC 8081"}, intendedUsage: UsageCommon},
+	"image/bmp":                    {references: []string{"RFC 7903"}, intendedUsage: UsageCommon},
+	"image/tiff":                   {references: []string{"RFC 3302"}},
+	"multipart/form-data":          {references: []string{"RFC 7578"}},
+	"multipart/related":            {references: []string{"RFC 2387"}},
+	"text/calendar":                {references: []string{"RFC 5545"}, intendedUsage: UsageCommon},
+	"text/css":                     {references: []string{"RFC 2318"}},
+	"text/csv":                     {references: []string{"RFC 4180", "RFC 7111"}, intendedUsage: UsageCommon},
+	"text/ecmascript":              {references: []string{"RFC 9239"}, intendedUsage: UsageObsolete},
+	"text/javascript":              {references: []string{"RFC 9239"}, intendedUsage: UsageCommon},
+	"text/markdown":                {references: []string{"RFC 7763"}, intendedUsage: UsageCommon},
+	"text/vcard":                   {references: []string{"RFC 6350"}, intendedUsage: UsageCommon},
+	"text/xml":                     {references: []string{"RFC 7303"}, intendedUsage: UsageCommon},
+	"video/mp4":                    {references: []string{"RFC 4337", "RFC 6381"}},
+	"video/ogg":                    {references: []string{"RFC 5334", "RFC 7845"}},
+}
@@ -0,0 +1,78 @@
+package mediatypes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMediaType_Registration(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		want      Registration
+	}{
+		{
+			name:      "registered",
+			mediaType: "application/json",
+			want: Registration{
+				Registered:    true,
+				References:    []string{"RFC 8259"},
+				Template:      "https://www.iana.org/assignments/media-types/application/json",
+				IntendedUsage: UsageCommon,
+			},
+		},
+		{
+			name:      "obsolete",
+			mediaType: "application/javascript",
+			want: Registration{
+				Registered:    true,
+				References:    []string{"RFC 9239"},
+				Template:      "https://www.iana.org/assignments/media-types/application/javascript",
+				IntendedUsage: UsageObsolete,
+			},
+		},
+		{
+			name:      "not curated",
+			mediaType: "image/png",
+			want: Registration{
+				Registered: true,
+				Template:   "https://www.iana.org/assignments/media-types/image/png",
+			},
+		},
+		{
+			name:      "unregistered",
+			mediaType: "application/x-javascript",
+			want:      Registration{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				m, ok := ByName(tt.mediaType)
+				if !ok {
+					t.Fatalf("ByName(%q) not found", tt.mediaType)
+				}
+				if got := m.Registration(); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Registration() = %+v, want %+v", got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestRegistrations_Registered(t *testing.T) {
+	for name := range registrations {
+		m, ok := ByName(name)
+		if !ok || !m.Registered() {
+			t.Errorf("registration metadata for unregistered media type %s", name)
+		}
+	}
+}
+
+func TestMediaType_Registration_Copy(t *testing.T) {
+	m, _ := ByName("text/csv")
+	m.Registration().References[0] = "x"
+	if got := m.Registration().References[0]; got != "RFC 4180" {
+		t.Errorf("References[0] = %q after modifying a returned slice, want %q", got, "RFC 4180")
+	}
+}
@@ -0,0 +1,87 @@
+package mediatypes
+
+import (
+	"strings"
+)
+
+// byName maps lower-cased media type names to their index in mediaTypes.
+var byName = func() map[string]int {
+	index := make(map[string]int, len(mediaTypes))
+	for i, m := range mediaTypes {
+		index[strings.ToLower(m.name)] = i
+	}
+	return index
+}()
+
+// ByName returns the media type with the given name, such as "image/gif".
+// Names are compared case-insensitively and any parameters are ignored.
+func ByName(name string) (MediaType, bool) {
+	if i := strings.IndexByte(name, ';'); i >= 0 {
+		name = name[:i]
+	}
+	i, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return MediaType{}, false
+	}
+	return mediaTypes[i], true
+}
+
+// All returns all media types, sorted by name. The returned slice is a copy
+// and may be modified by the caller.
+func All() []MediaType {
+	result := make([]MediaType, len(mediaTypes))
+	copy(result, mediaTypes)
+	return result
+}
+
+// Range calls fn for each media type, sorted by name. If fn returns false,
+// Range stops the iteration.
+func Range(fn func(MediaType) bool) {
+	for _, m := range mediaTypes {
+		if !fn(m) {
+			return
+		}
+	}
+}
+
+// ByTopLevelType returns the media types with the given top-level type, such
+// as "image" or "text", sorted by name.
+func ByTopLevelType(typ string) []MediaType {
+	prefix := strings.ToLower(typ) + "/"
+	return collect(
+		func(m MediaType) bool {
+			return strings.HasPrefix(strings.ToLower(m.name), prefix)
+		},
+	)
+}
+
+// ByFormat returns the media types with the given format, such as
+// "application/zip" or "text/xml", sorted by name.
+func ByFormat(format string) []MediaType {
+	return collect(
+		func(m MediaType) bool {
+			return m.format != "" && strings.EqualFold(m.format, format)
+		},
+	)
+}
+
+// RegisteredOnly returns the media types that are registered with IANA,
+// sorted by name.
+func RegisteredOnly() []MediaType {
+	return collect(
+		func(m MediaType) bool {
+			return m.registered
+		},
+	)
+}
+
+// collect returns the media types for which keep returns true.
+func collect(keep func(MediaType) bool) []MediaType {
+	var result []MediaType
+	for _, m := range mediaTypes {
+		if keep(m) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
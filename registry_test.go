@@ -0,0 +1,95 @@
+package mediatypes
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestByName(t *testing.T) {
+	tests := []struct {
+		name   string
+		lookup string
+		want   string
+		wantOk bool
+	}{
+		{name: "exact", lookup: "image/gif", want: "image/gif", wantOk: true},
+		{name: "case-insensitive", lookup: "Application/3GPPHAL+JSON", want: "application/3gppHal+json", wantOk: true},
+		{name: "parameters", lookup: "text/plain; charset=utf-8", want: "text/plain", wantOk: true},
+		{name: "unknown", lookup: "image/unknown", wantOk: false},
+		{name: "empty", lookup: "", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, ok := ByName(tt.lookup)
+				if ok != tt.wantOk || got.Name() != tt.want {
+					t.Errorf("ByName(%q) = %q, %v, want %q, %v", tt.lookup, got.Name(), ok, tt.want, tt.wantOk)
+				}
+			},
+		)
+	}
+}
+
+func TestAll(t *testing.T) {
+	all := All()
+	if len(all) != len(mediaTypes) {
+		t.Fatalf("len(All()) = %d, want %d", len(all), len(mediaTypes))
+	}
+	if !sort.SliceIsSorted(all, func(i, j int) bool { return all[i].name < all[j].name }) {
+		t.Error("All() is not sorted by name")
+	}
+	first := mediaTypes[0].name
+	all[0] = MediaType{name: "x/x"}
+	if mediaTypes[0].name != first {
+		t.Error("modifying the result of All() modified the registry")
+	}
+}
+
+func TestRange(t *testing.T) {
+	var count int
+	Range(
+		func(m MediaType) bool {
+			count++
+			return count < 3
+		},
+	)
+	if count != 3 {
+		t.Errorf("Range() called fn %d times, want 3", count)
+	}
+}
+
+func TestByTopLevelType(t *testing.T) {
+	got := ByTopLevelType("Image")
+	if len(got) == 0 {
+		t.Fatal("ByTopLevelType() returned no media types")
+	}
+	for _, m := range got {
+		if !strings.HasPrefix(m.Name(), "image/") {
+			t.Errorf("ByTopLevelType() returned %s", m.Name())
+		}
+	}
+}
+
+func TestByFormat(t *testing.T) {
+	got := ByFormat("application/zip")
+	if len(got) == 0 {
+		t.Fatal("ByFormat() returned no media types")
+	}
+	for _, m := range got {
+		if m.Format() != "application/zip" {
+			t.Errorf("ByFormat() returned %s with format %q", m.Name(), m.Format())
+		}
+	}
+	if got := ByFormat(""); got != nil {
+		t.Errorf("ByFormat(\"\") = %v, want nil", got)
+	}
+}
+
+func TestRegisteredOnly(t *testing.T) {
+	for _, m := range RegisteredOnly() {
+		if !m.Registered() {
+			t.Errorf("RegisteredOnly() returned %s", m.Name())
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package mediatypes
+
+import (
+	"mime"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Evidence holds the signals available about the media type of some content.
+// Any of them may be empty.
+type Evidence struct {
+	// Filename is the name of the file, such as "report.docx".
+	Filename string
+
+	// Declared is the declared media type, such as the value of a
+	// Content-Type header.
+	Declared string
+
+	// Prefix holds the first bytes of the content.
+	Prefix []byte
+}
+
+// Signal identifies a kind of evidence.
+type Signal string
+
+const (
+	// SignalExtension is the extension of the file name.
+	SignalExtension Signal = "extension"
+
+	// SignalDeclared is the declared media type.
+	SignalDeclared Signal = "declared"
+
+	// SignalContent is the media type detected from the content.
+	SignalContent Signal = "content"
+)
+
+// Relation describes how a signal relates to a candidate media type.
+type Relation string
+
+const (
+	// RelationExact means the signal names the candidate itself.
+	RelationExact Relation = "exact"
+
+	// RelationGeneralizes means the signal names a more general media type
+	// that is consistent with the candidate, such as "application/zip" for a
+	// Word document.
+	RelationGeneralizes Relation = "generalizes"
+
+	// RelationSpecializes means the signal names a more specific media type
+	// than the candidate, such as a Word document for "application/zip".
+	RelationSpecializes Relation = "specializes"
+)
+
+// Reason explains how one signal contributed to the score of a candidate.
+type Reason struct {
+	// Signal is the kind of evidence.
+	Signal Signal `json:"signal"`
+
+	// Value is the evidence itself, such as the extension or the media type
+	// name.
+	Value string `json:"value"`
+
+	// Relation is how the evidence relates to the candidate.
+	Relation Relation `json:"relation"`
+
+	// Score is the contribution to the score of the candidate.
+	Score float64 `json:"score"`
+}
+
+// Candidate is a media type suggested by Resolve.
+type Candidate struct {
+	// MediaType is the suggested media type.
+	MediaType MediaType `json:"mediaType"`
+
+	// Score is between 0 and 1; higher is better.
+	Score float64 `json:"score"`
+
+	// Reasons explains the score.
+	Reasons []Reason `json:"reasons"`
+}
+
+const (
+	// contentWeight is the weight of a specific media type detected from the
+	// content, and genericContentWeight that of a generic one.
+	contentWeight        = 0.5
+	genericContentWeight = 0.2
+
+	// declaredWeight is the weight of the declared media type.
+	declaredWeight = 0.3
+
+	// extensionWeight is the weight of the file extension. It is shared
+	// between the media types the extension is associated with.
+	extensionWeight = 0.2
+
+	// generalizesFactor and specializesFactor scale the weight of a signal
+	// that is consistent with, rather than equal to, a candidate.
+	generalizesFactor = 0.8
+	specializesFactor = 0.5
+)
+
+// genericMediaTypes holds the names of media types that other media types
+// are specializations of.
+var genericMediaTypes = map[string]bool{
+	"application/json":         true,
+	"application/octet-stream": true,
+	"application/xml":          true,
+	"application/zip":          true,
+	"text/plain":               true,
+	"text/xml":                 true,
+}
+
+// Resolve weighs the file name, declared media type and content in the
+// evidence and returns the candidate media types, best first. Signals that
+// name a more general media type support the more specific ones they are
+// consistent with, so content sniffed as "application/zip" supports a
+// declared Word document. Content outweighs the declared media type, which
+// outweighs the extension. A declared "application/octet-stream" is ignored,
+// as clients use it when they do not know better.
+func Resolve(e Evidence) []Candidate {
+	type signal struct {
+		signal    Signal
+		value     string
+		mediaType MediaType
+		weight    float64
+	}
+	var signals []signal
+
+	if ext := strings.TrimPrefix(path.Ext(e.Filename), "."); ext != "" {
+		matches := ByExtension(strings.ToLower(ext))
+		for _, m := range matches {
+			signals = append(signals, signal{SignalExtension, ext, m, extensionWeight / float64(len(matches))})
+		}
+	}
+	if name, _, err := mime.ParseMediaType(e.Declared); err == nil && name != "application/octet-stream" {
+		signals = append(signals, signal{SignalDeclared, name, lookup(name), declaredWeight})
+	}
+	if len(e.Prefix) > 0 {
+		m := Detect(e.Prefix)
+		weight := contentWeight
+		if genericMediaTypes[strings.ToLower(m.name)] {
+			weight = genericContentWeight
+		}
+		signals = append(signals, signal{SignalContent, m.name, m, weight})
+	}
+
+	// Every media type named by a signal is a candidate. The content signal
+	// is added last so that its parameters, such as the charset, are kept.
+	candidates := make(map[string]*Candidate)
+	var order []string
+	total := 0.0
+	for _, s := range signals {
+		key := strings.ToLower(s.mediaType.name)
+		if c, ok := candidates[key]; ok {
+			if s.signal == SignalContent {
+				c.MediaType = s.mediaType
+			}
+		} else {
+			candidates[key] = &Candidate{MediaType: s.mediaType}
+			order = append(order, key)
+		}
+	}
+	seen := make(map[Signal]bool)
+	for _, s := range signals {
+		if !seen[s.signal] {
+			seen[s.signal] = true
+			switch s.signal {
+			case SignalExtension:
+				total += extensionWeight
+			default:
+				total += s.weight
+			}
+		}
+	}
+
+	for _, key := range order {
+		c := candidates[key]
+		for _, s := range signals {
+			var relation Relation
+			factor := 1.0
+			switch {
+			case strings.EqualFold(s.mediaType.name, c.MediaType.name):
+				relation = RelationExact
+			case specializes(c.MediaType, s.mediaType):
+				relation, factor = RelationGeneralizes, generalizesFactor
+			case specializes(s.mediaType, c.MediaType):
+				relation, factor = RelationSpecializes, specializesFactor
+			default:
+				continue
+			}
+			score := s.weight * factor / total
+			c.Score += score
+			c.Reasons = append(c.Reasons, Reason{Signal: s.signal, Value: s.value, Relation: relation, Score: score})
+		}
+	}
+
+	result := make([]Candidate, 0, len(order))
+	for _, key := range order {
+		result = append(result, *candidates[key])
+	}
+	sort.SliceStable(
+		result, func(i, j int) bool {
+			return result[i].Score > result[j].Score
+		},
+	)
+	return result
+}
+
+// specializes returns true if m is a more specific form of general, such as
+// a Word document of "application/zip", or an SVG image of "text/xml".
+func specializes(m, general MediaType) bool {
+	name := strings.ToLower(m.name)
+	switch strings.ToLower(general.name) {
+	case name:
+		return false
+	case "application/octet-stream":
+		return true
+	case "text/plain":
+		return isTextual(m)
+	case "text/xml", "application/xml":
+		return m.format == "text/xml" || name == "text/xml" || name == "application/xml"
+	case "application/zip":
+		return m.format == "application/zip" || isZipContainer(name)
+	case "application/json":
+		return m.format == "application/json"
+	}
+	return false
+}
+
+// isZipContainer returns true if the named media type is a ZIP-based
+// container format recognized by DetectContainer.
+func isZipContainer(name string) bool {
+	switch name {
+	case "application/java-archive", "application/vnd.android.package-archive", "application/x-xpinstall":
+		return true
+	}
+	return strings.HasPrefix(name, "application/vnd.openxmlformats-officedocument.") ||
+		strings.HasPrefix(name, "application/vnd.oasis.opendocument.") ||
+		(strings.HasPrefix(name, "application/vnd.ms-") && strings.HasSuffix(name, ".macroenabled.12"))
+}
@@ -0,0 +1,98 @@
+package mediatypes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	docx := makeZipPrefix(
+		t,
+		contentTypes("application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"),
+	)
+	tests := []struct {
+		name     string
+		evidence Evidence
+		want     string
+	}{
+		{
+			name:     "extension only",
+			evidence: Evidence{Filename: "photo.GIF"},
+			want:     "image/gif",
+		},
+		{
+			name:     "declared only",
+			evidence: Evidence{Declared: "Image/PNG; charset=binary"},
+			want:     "image/png",
+		},
+		{
+			name:     "content outweighs declared",
+			evidence: Evidence{Declared: "image/png", Prefix: []byte("%PDF-1.7\n")},
+			want:     "application/pdf",
+		},
+		{
+			name: "generic content is consistent with declared",
+			evidence: Evidence{
+				Declared: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+				Prefix:   []byte("PK\x03\x04\x14\x00\x00\x00\x08\x00"),
+			},
+			want: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		},
+		{
+			name:     "specific content",
+			evidence: Evidence{Filename: "report.zip", Prefix: docx},
+			want:     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		},
+		{
+			name:     "octet-stream is ignored",
+			evidence: Evidence{Filename: "data.csv", Declared: "application/octet-stream", Prefix: []byte("a,b\n1,2\n3,4\n")},
+			want:     "text/csv",
+		},
+		{
+			name:     "extension breaks a tie with generic content",
+			evidence: Evidence{Filename: "notes.md", Prefix: []byte("Some notes.\n")},
+			want:     "text/x-markdown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got := Resolve(tt.evidence)
+				if len(got) == 0 {
+					t.Fatalf("Resolve() returned no candidates, want %s", tt.want)
+				}
+				if got[0].MediaType.Name() != tt.want {
+					t.Errorf("Resolve()[0] = %s, want %s; candidates: %+v", got[0].MediaType.Name(), tt.want, got)
+				}
+				for i, c := range got {
+					if c.Score < 0 || c.Score > 1.000001 {
+						t.Errorf("Resolve()[%d].Score = %v, want between 0 and 1", i, c.Score)
+					}
+					if i > 0 && c.Score > got[i-1].Score {
+						t.Errorf("Resolve() is not ranked: %v before %v", got[i-1].Score, c.Score)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestResolve_Empty(t *testing.T) {
+	if got := Resolve(Evidence{}); len(got) != 0 {
+		t.Errorf("Resolve() = %v, want no candidates", got)
+	}
+}
+
+func TestResolve_Explanation(t *testing.T) {
+	got := Resolve(Evidence{Filename: "a.csv", Prefix: []byte("a,b\n1,2\n3,4\n")})
+	data, err := json.Marshal(got[0])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	for _, want := range []string{`"mediaType":"text/csv"`, `"signal":"extension"`, `"signal":"content"`, `"relation":"exact"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("json.Marshal() = %s, want it to contain %s", data, want)
+		}
+	}
+}
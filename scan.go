@@ -0,0 +1,346 @@
+package mediatypes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+)
+
+// Flag identifies a dangerous combination of formats found by Scan.
+type Flag string
+
+const (
+	// FlagImageArchive means an image is also a ZIP archive, as in a GIFAR:
+	// a GIF image that is also a Java archive.
+	FlagImageArchive Flag = "image-archive"
+
+	// FlagPDFArchive means a PDF document is also a ZIP archive.
+	FlagPDFArchive Flag = "pdf-archive"
+
+	// FlagHTMLPrologue means content of another format begins with HTML
+	// markup, so a browser that sniffs it may render it as HTML.
+	FlagHTMLPrologue Flag = "html-prologue"
+
+	// FlagEmbeddedHTML means binary content contains HTML markup, for
+	// example in the comment of an image.
+	FlagEmbeddedHTML Flag = "embedded-html"
+)
+
+// ScanMatch is a format found by Scan.
+type ScanMatch struct {
+	// MediaType is the format.
+	MediaType MediaType `json:"mediaType"`
+
+	// Offset is where the format begins in the content, or 0 if the
+	// signature does not locate it.
+	Offset int `json:"offset"`
+}
+
+// ScanResult is the result of Scan.
+type ScanResult struct {
+	// MediaType is the media type Detect reports for the content.
+	MediaType MediaType `json:"mediaType"`
+
+	// Matches holds every format the content matches, in the order of their
+	// offsets.
+	Matches []ScanMatch `json:"matches"`
+
+	// Flags holds the dangerous combinations among the matches.
+	Flags []Flag `json:"flags"`
+}
+
+// Polyglot returns true if the content matches more than one format.
+func (r *ScanResult) Polyglot() bool {
+	return len(r.Matches) > 1
+}
+
+// Has returns true if the result has the given flag.
+func (r *ScanResult) Has(f Flag) bool {
+	for _, g := range r.Flags {
+		if g == f {
+			return true
+		}
+	}
+	return false
+}
+
+// scanStart returns the format Scan finds at the start of data, if any.
+func scanStart(data []byte) (MediaType, bool) {
+	for _, m := range Scan(data).Matches {
+		if m.Offset == 0 {
+			return m.MediaType, true
+		}
+	}
+	return MediaType{}, false
+}
+
+// scanSignature finds a format in content and returns where it begins.
+type scanSignature struct {
+	name string
+	find func(data []byte) (int, bool)
+}
+
+// scanSignatures are the formats Scan looks for in addition to what Detect
+// reports. Unlike Detect, they include formats that are read from the end of
+// the content or found at an offset.
+var scanSignatures = []scanSignature{
+	{name: "image/gif", find: prefixAt(0, "GIF87a", "GIF89a")},
+	{name: "image/png", find: prefixAt(0, "\x89PNG\r\n\x1a\n")},
+	{name: "image/jpeg", find: prefixAt(0, "\xff\xd8\xff")},
+	{name: "image/tiff", find: prefixAt(0, "II*\x00", "MM\x00*")},
+	{name: "image/bmp", find: prefixAt(0, "BM")},
+	{name: "image/webp", find: findWebP},
+	{name: "application/pdf", find: findPDF},
+	{name: "application/zip", find: findZip},
+	{name: "application/gzip", find: prefixAt(0, "\x1f\x8b\x08")},
+	{name: "application/vnd.microsoft.portable-executable", find: findPE},
+	{name: "text/html", find: findHTML},
+}
+
+// Scan runs every signature against the content instead of stopping at the
+// best match, and reports each format the content matches. It flags
+// combinations that are used to smuggle content past upload filters, such as
+// an image that is also a ZIP archive or binary content with HTML markup.
+//
+// Scan considers all of data, since formats such as ZIP are read from the end
+// of a file; callers should limit the size of untrusted content.
+func Scan(data []byte) ScanResult {
+	r := ScanResult{MediaType: Detect(data)}
+	seen := make(map[string]int)
+	add := func(m MediaType, offset int) {
+		name := strings.ToLower(m.name)
+		if name == "application/octet-stream" {
+			return
+		}
+		if i, ok := seen[name]; ok {
+			if offset < r.Matches[i].Offset {
+				r.Matches[i].Offset = offset
+			}
+			return
+		}
+		seen[name] = len(r.Matches)
+		r.Matches = append(r.Matches, ScanMatch{MediaType: m, Offset: offset})
+	}
+
+	add(r.MediaType, 0)
+	for _, s := range scanSignatures {
+		offset, ok := s.find(data)
+		if !ok {
+			continue
+		}
+		m := lookup(s.name)
+		if s.name == "application/zip" {
+			c, refined := scanZip(data[offset:])
+			if offset == 0 && isArchive(r.MediaType) {
+				// Detect reported the archive from a prefix; the whole
+				// content may identify it better.
+				if refined {
+					delete(seen, strings.ToLower(r.Matches[0].MediaType.name))
+					seen[strings.ToLower(c.name)] = 0
+					r.Matches[0].MediaType = c
+				}
+				continue
+			}
+			if refined {
+				m = c
+			}
+		}
+		add(m, offset)
+	}
+	customSignatures.RLock()
+	list := customSignatures.list
+	customSignatures.RUnlock()
+	for _, s := range list {
+		if s.matcher.match(data) {
+			add(lookup(s.name), 0)
+		}
+	}
+	sortScanMatches(r.Matches)
+
+	r.Flags = scanFlags(r.Matches)
+	return r
+}
+
+// scanFlags returns the dangerous combinations among the matches.
+func scanFlags(matches []ScanMatch) []Flag {
+	var image, pdf, archive, binary, htmlPrologue, htmlEmbedded bool
+	for _, m := range matches {
+		name := strings.ToLower(m.MediaType.name)
+		switch {
+		case name == "text/html":
+			if m.Offset == 0 {
+				htmlPrologue = true
+			} else {
+				htmlEmbedded = true
+			}
+			continue
+		case strings.HasPrefix(name, "image/"):
+			image = true
+		case name == "application/pdf":
+			pdf = true
+		case isArchive(m.MediaType):
+			archive = true
+		}
+		if !isTextual(m.MediaType) {
+			binary = true
+		}
+	}
+
+	var flags []Flag
+	if image && archive {
+		flags = append(flags, FlagImageArchive)
+	}
+	if pdf && archive {
+		flags = append(flags, FlagPDFArchive)
+	}
+	if htmlPrologue && len(matches) > 1 {
+		flags = append(flags, FlagHTMLPrologue)
+	}
+	if htmlEmbedded && binary {
+		flags = append(flags, FlagEmbeddedHTML)
+	}
+	return flags
+}
+
+// sortScanMatches sorts matches by offset, keeping the order of matches at
+// the same offset.
+func sortScanMatches(matches []ScanMatch) {
+	for i := 1; i < len(matches); i++ {
+		for j := i; j > 0 && matches[j].Offset < matches[j-1].Offset; j-- {
+			matches[j], matches[j-1] = matches[j-1], matches[j]
+		}
+	}
+}
+
+// isArchive returns true if m is a ZIP archive or a format based on it.
+func isArchive(m MediaType) bool {
+	name := strings.ToLower(m.name)
+	return name == "application/zip" || m.format == "application/zip" || isZipContainer(name)
+}
+
+// prefixAt returns a function that finds any of the prefixes at offset.
+func prefixAt(offset int, prefixes ...string) func([]byte) (int, bool) {
+	return func(data []byte) (int, bool) {
+		if offset > len(data) {
+			return 0, false
+		}
+		for _, p := range prefixes {
+			if bytes.HasPrefix(data[offset:], []byte(p)) {
+				return offset, true
+			}
+		}
+		return 0, false
+	}
+}
+
+// findWebP finds a WebP image, which is a RIFF file of type "WEBP".
+func findWebP(data []byte) (int, bool) {
+	return 0, len(data) >= 12 && bytes.HasPrefix(data, []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP"))
+}
+
+// findPDF finds a PDF header in the first 1024 bytes, where PDF readers
+// accept it.
+func findPDF(data []byte) (int, bool) {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	i := bytes.Index(head, []byte("%PDF-"))
+	return i, i >= 0
+}
+
+// findZip finds a ZIP archive, which may follow other content since readers
+// locate it from the end-of-central-directory record. It returns the offset
+// of the first local file header.
+func findZip(data []byte) (int, bool) {
+	// The end-of-central-directory record is 22 bytes plus a comment of up
+	// to 65535 bytes.
+	tail := 0
+	if len(data) > 22+65535 {
+		tail = len(data) - 22 - 65535
+	}
+	if bytes.LastIndex(data[tail:], []byte("PK\x05\x06")) < 0 {
+		// Without a directory, only an archive at the start is readable as
+		// a stream.
+		return 0, bytes.HasPrefix(data, []byte("PK\x03\x04"))
+	}
+	i := bytes.Index(data, []byte("PK\x03\x04"))
+	if i < 0 {
+		// An empty archive has no local file headers.
+		i = bytes.LastIndex(data[tail:], []byte("PK\x05\x06")) + tail
+	}
+	return i, true
+}
+
+// scanZip returns the container format of the archive that begins data,
+// reading its central directory if it has one.
+func scanZip(data []byte) (MediaType, bool) {
+	if m, err := DetectContainer(bytes.NewReader(data), int64(len(data))); err == nil {
+		return m, !strings.EqualFold(m.name, "application/zip")
+	}
+	return detectZipPrefix(data)
+}
+
+// findPE finds a Windows executable: a DOS header that points to a PE
+// header.
+func findPE(data []byte) (int, bool) {
+	if len(data) < 0x40 || !bytes.HasPrefix(data, []byte("MZ")) {
+		return 0, false
+	}
+	offset := int(binary.LittleEndian.Uint32(data[0x3c:]))
+	return 0, offset >= 0x40 && offset+4 <= len(data) && bytes.Equal(data[offset:offset+4], []byte("PE\x00\x00"))
+}
+
+// htmlPrologueMarkers are the tags that make a browser treat content that
+// begins with them as HTML, as in the HTML patterns of the WHATWG MIME
+// Sniffing Standard.
+var htmlPrologueMarkers = []string{
+	"<!doctype html", "<html", "<head", "<script", "<iframe", "<h1", "<div",
+	"<font", "<table", "<a", "<style", "<title", "<b", "<body", "<br", "<p",
+	"<!--",
+}
+
+// htmlEmbeddedMarkers are the tags that suggest HTML inside other content.
+// Short tags such as "<a" occur in binary data by chance, so they are left
+// out.
+var htmlEmbeddedMarkers = []string{
+	"<!doctype html", "<html", "<head", "<script", "<iframe", "<body", "<svg",
+	"<img", "<object", "<embed",
+}
+
+// findHTML finds HTML markup. Markup at the start of the content, after
+// whitespace, is reported at offset 0.
+func findHTML(data []byte) (int, bool) {
+	start := 0
+	for start < len(data) && isHTMLSpace(data[start]) {
+		start++
+	}
+	if hasHTMLMarker(data[start:], htmlPrologueMarkers) {
+		return 0, true
+	}
+	for i := start + 1; i < len(data); i++ {
+		if data[i] == '<' && hasHTMLMarker(data[i:], htmlEmbeddedMarkers) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// hasHTMLMarker returns true if data begins with one of the markers
+// followed by a space or ">", or with a comment.
+func hasHTMLMarker(data []byte, markers []string) bool {
+	for _, m := range markers {
+		if len(data) <= len(m) || !bytes.EqualFold(data[:len(m)], []byte(m)) {
+			continue
+		}
+		if m == "<!--" || data[len(m)] == '>' || isHTMLSpace(data[len(m)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHTMLSpace returns true if c is whitespace in HTML.
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,65 @@
+package mediatypes
+
+import (
+	"archive/zip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	gif := "GIF89a\x01\x00\x01\x00\x80\x00\x00\xff\xff\xff\x00\x00\x00,\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02D\x01\x00;"
+	jar := string(makeZip(t, zipEntry{name: "META-INF/MANIFEST.MF", body: "Manifest-Version: 1.0\n", method: zip.Store}))
+	docx := string(makeZip(t, contentTypes("application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml")))
+	pdf := "%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n"
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00"
+
+	tests := []struct {
+		name      string
+		data      string
+		want      []string
+		wantFlags []Flag
+	}{
+		{name: "plain image", data: gif, want: []string{"image/gif"}},
+		{name: "plain archive", data: docx, want: []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document"}},
+		{name: "gifar", data: gif + jar, want: []string{"image/gif", "application/java-archive"}, wantFlags: []Flag{FlagImageArchive}},
+		{name: "pdf and zip", data: pdf + string(makeZip(t, zipEntry{name: "a.txt", body: "a"})), want: []string{"application/pdf", "application/zip"}, wantFlags: []Flag{FlagPDFArchive}},
+		{name: "html in image", data: png + "tEXtComment\x00<script>alert(1)</script>", want: []string{"image/png", "text/html"}, wantFlags: []Flag{FlagEmbeddedHTML}},
+		{name: "html prologue", data: "<html><!-- -->\n" + pdf, want: []string{"text/html", "application/pdf"}, wantFlags: []Flag{FlagHTMLPrologue}},
+		{name: "plain html", data: "<!DOCTYPE html><p>Hello</p>", want: []string{"text/html"}},
+		{name: "short tag in binary", data: png + "<a ", want: []string{"image/png"}},
+		{name: "unknown", data: "\x00\x01\x02\x03", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := Scan([]byte(tt.data))
+				var got []string
+				for _, m := range r.Matches {
+					got = append(got, m.MediaType.Name())
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Scan() matches = %v, want %v", got, tt.want)
+				}
+				if !reflect.DeepEqual(r.Flags, tt.wantFlags) {
+					t.Errorf("Scan() flags = %v, want %v", r.Flags, tt.wantFlags)
+				}
+				if r.Polyglot() != (len(tt.want) > 1) {
+					t.Errorf("Polyglot() = %v, want %v", r.Polyglot(), len(tt.want) > 1)
+				}
+			},
+		)
+	}
+}
+
+func TestScan_Offsets(t *testing.T) {
+	jar := makeZip(t, zipEntry{name: "META-INF/MANIFEST.MF", body: "Manifest-Version: 1.0\n", method: zip.Store})
+	prefix := "GIF89a" + strings.Repeat("\x00", 100)
+	r := Scan(append([]byte(prefix), jar...))
+	if len(r.Matches) != 2 || r.Matches[1].Offset != len(prefix) {
+		t.Fatalf("Scan() matches = %v, want the archive at offset %d", r.Matches, len(prefix))
+	}
+	if !r.Has(FlagImageArchive) || r.Has(FlagPDFArchive) {
+		t.Errorf("Scan() flags = %v, want %v", r.Flags, []Flag{FlagImageArchive})
+	}
+}
@@ -0,0 +1,249 @@
+package mediatypes
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MatchKind describes how a search query matched a media type.
+type MatchKind int
+
+const (
+	// MatchFuzzy means a token of the field is within a small edit distance
+	// of the query.
+	MatchFuzzy MatchKind = iota + 1
+
+	// MatchToken means a token of the field starts with or contains the
+	// query.
+	MatchToken
+
+	// MatchPrefix means the field starts with the query.
+	MatchPrefix
+
+	// MatchExact means the field is equal to the query.
+	MatchExact
+)
+
+// String returns the name of the match kind.
+func (k MatchKind) String() string {
+	switch k {
+	case MatchExact:
+		return "exact"
+	case MatchPrefix:
+		return "prefix"
+	case MatchToken:
+		return "token"
+	case MatchFuzzy:
+		return "fuzzy"
+	}
+	return "unknown"
+}
+
+// MatchField identifies the part of a media type that matched a search query.
+type MatchField int
+
+const (
+	// FieldName is the media type name, such as "image/gif".
+	FieldName MatchField = iota + 1
+
+	// FieldExtension is one of the file extensions of the media type.
+	FieldExtension
+
+	// FieldDescription is the human-readable description of the media type.
+	FieldDescription
+)
+
+// String returns the name of the field.
+func (f MatchField) String() string {
+	switch f {
+	case FieldName:
+		return "name"
+	case FieldExtension:
+		return "extension"
+	case FieldDescription:
+		return "description"
+	}
+	return "unknown"
+}
+
+// Match is a search result.
+type Match struct {
+	// MediaType is the media type that matched.
+	MediaType MediaType
+
+	// Field is the part of the media type that matched.
+	Field MatchField
+
+	// Kind is how the query matched the field.
+	Kind MatchKind
+
+	// Value is the value of the field that matched, such as the extension
+	// or the name token.
+	Value string
+
+	// Score ranks the match; higher is better.
+	Score int
+}
+
+// Search returns the media types that match the query, best matches first.
+// Names are tokenized on "/", ".", "-" and "+", so "mpeg" matches
+// "video/mpeg" and "audio/mpeg", and descriptions are tokenized into words,
+// so "word" matches "Microsoft Word document". Queries are case-insensitive.
+// If limit is positive, at most limit matches are returned.
+func Search(query string, limit int) []Match {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	var result []Match
+	for _, m := range mediaTypes {
+		if match, ok := searchMediaType(m, query); ok {
+			result = append(result, match)
+		}
+	}
+
+	sort.SliceStable(
+		result, func(i, j int) bool {
+			if result[i].Score != result[j].Score {
+				return result[i].Score > result[j].Score
+			}
+			return len(result[i].MediaType.name) < len(result[j].MediaType.name)
+		},
+	)
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// searchMediaType returns the best match of query against m.
+func searchMediaType(m MediaType, query string) (Match, bool) {
+	best := Match{MediaType: m}
+	consider := func(field MatchField, value string, tokens []string) {
+		kind, score, matched := matchField(value, tokens, query)
+		if kind == 0 {
+			return
+		}
+		// Prefer names over extensions, and extensions over descriptions, when
+		// the kind of match is the same.
+		score = score*4 - int(field)
+		if score > best.Score {
+			best.Field = field
+			best.Kind = kind
+			best.Value = matched
+			best.Score = score
+		}
+	}
+
+	name := strings.ToLower(m.name)
+	consider(FieldName, name, tokenize(name))
+	for _, ext := range m.extensions {
+		ext = strings.ToLower(ext)
+		consider(FieldExtension, ext, nil)
+	}
+	if d, ok := descriptions[m.name]; ok && d.text != "" {
+		text := strings.ToLower(d.text)
+		consider(FieldDescription, text, strings.FieldsFunc(text, isWordSeparator))
+	}
+	return best, best.Kind != 0
+}
+
+// matchField matches query against a field value and its tokens. It returns
+// the kind of match, a score and the part of the value that matched.
+func matchField(value string, tokens []string, query string) (MatchKind, int, string) {
+	switch {
+	case value == query:
+		return MatchExact, 400, value
+	case strings.HasPrefix(value, query):
+		return MatchPrefix, 300, value
+	}
+
+	var (
+		kind    MatchKind
+		score   int
+		matched string
+	)
+	if tokens == nil {
+		tokens = []string{value}
+	}
+	for _, token := range tokens {
+		switch {
+		case token == query:
+			return MatchToken, 250, token
+		case strings.HasPrefix(token, query):
+			if score < 225 {
+				kind, score, matched = MatchToken, 225, token
+			}
+		case strings.Contains(token, query):
+			if score < 200 {
+				kind, score, matched = MatchToken, 200, token
+			}
+		default:
+			max := maxEditDistance(query)
+			if max == 0 {
+				continue
+			}
+			if d := editDistance(token, query); d <= max && score < 100-d*10 {
+				kind, score, matched = MatchFuzzy, 100-d*10, token
+			}
+		}
+	}
+	return kind, score, matched
+}
+
+// tokenize splits a media type name on "/", ".", "-" and "+".
+func tokenize(name string) []string {
+	return strings.FieldsFunc(
+		name, func(r rune) bool {
+			return r == '/' || r == '.' || r == '-' || r == '+'
+		},
+	)
+}
+
+// isWordSeparator returns true if r separates the words of a description.
+func isWordSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// maxEditDistance returns the largest edit distance at which a token still
+// fuzzily matches the query. Short queries must match exactly.
+func maxEditDistance(query string) int {
+	switch {
+	case len(query) < 4:
+		return 0
+	case len(query) < 7:
+		return 1
+	}
+	return 2
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// minInt returns the smaller of a and b.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,58 @@
+package mediatypes
+
+import (
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantFirst string
+		wantField MatchField
+		wantKind  MatchKind
+	}{
+		{name: "exact name", query: "image/gif", wantFirst: "image/gif", wantField: FieldName, wantKind: MatchExact},
+		{name: "exact extension", query: "docx", wantFirst: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", wantField: FieldExtension, wantKind: MatchExact},
+		{name: "prefix", query: "IMAGE/GI", wantFirst: "image/gif", wantField: FieldName, wantKind: MatchPrefix},
+		{name: "extension before token", query: "mpeg", wantFirst: "video/mpeg", wantField: FieldExtension, wantKind: MatchExact},
+		{name: "token", query: "spreadsheetml", wantField: FieldName, wantKind: MatchToken},
+		{name: "description", query: "business", wantFirst: "text/vcard", wantField: FieldDescription, wantKind: MatchToken},
+		{name: "fuzzy", query: "postscrpt", wantFirst: "application/postscript", wantField: FieldName, wantKind: MatchFuzzy},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got := Search(tt.query, 1)
+				if len(got) != 1 {
+					t.Fatalf("Search(%q) returned %d matches, want 1", tt.query, len(got))
+				}
+				if (tt.wantFirst != "" && got[0].MediaType.Name() != tt.wantFirst) || got[0].Field != tt.wantField || got[0].Kind != tt.wantKind {
+					t.Errorf(
+						"Search(%q) = %s (%s, %s), want %s (%s, %s)",
+						tt.query, got[0].MediaType.Name(), got[0].Field, got[0].Kind,
+						tt.wantFirst, tt.wantField, tt.wantKind,
+					)
+				}
+			},
+		)
+	}
+}
+
+func TestSearch_Ranking(t *testing.T) {
+	got := Search("geo", 0)
+	if len(got) < 2 {
+		t.Fatalf("Search(\"geo\") returned %d matches, want at least 2", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Score > got[i-1].Score {
+			t.Fatalf("Search(\"geo\") is not ranked: %v before %v", got[i-1], got[i])
+		}
+	}
+	if got := Search("", 10); got != nil {
+		t.Errorf("Search(\"\") = %v, want nil", got)
+	}
+	if got := Search("geo", 3); len(got) != 3 {
+		t.Errorf("Search(\"geo\", 3) returned %d matches, want 3", len(got))
+	}
+}
@@ -0,0 +1,444 @@
+package mediatypes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultPriority is the priority of a signature that does not set one.
+// Signatures with at least this priority are checked before the built-in
+// detection; those with a lower priority only if the built-in detection does
+// not recognize the content.
+const DefaultPriority = 50
+
+// MaxSignatureOffset is the largest offset a signature rule may test. It
+// matches DefaultMaxBytes, beyond which DetectReaderAt reads nothing. Detect
+// only considers the first 512 bytes, so rules that test beyond them match
+// only in Scan, or in DetectReaderAt with a sniff size raised by
+// WithSniffSize.
+const MaxSignatureOffset = DefaultMaxBytes
+
+// SignatureError describes an invalid signature definition.
+type SignatureError struct {
+	// Path locates the invalid rule, such as "signatures[0].match.all[1]".
+	Path string
+
+	// Message describes the problem.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("mediatypes: invalid signature at %s: %s", e.Path, e.Message)
+}
+
+// Signatures is a compiled set of signatures.
+type Signatures struct {
+	list []signature
+}
+
+// Len returns the number of signatures in the set.
+func (s *Signatures) Len() int {
+	return len(s.list)
+}
+
+// signature identifies a media type by the content.
+type signature struct {
+	name     string
+	priority int
+	matcher  matcher
+}
+
+// matcher tests content.
+type matcher interface {
+	match(data []byte) bool
+}
+
+// byteTest matches bytes, under an optional mask, at any offset in the range
+// [from, to].
+type byteTest struct {
+	from, to int
+	value    []byte
+	mask     []byte
+}
+
+func (t *byteTest) match(data []byte) bool {
+	for offset := t.from; offset <= t.to && offset <= len(data)-len(t.value); offset++ {
+		if t.mask == nil {
+			if bytes.Equal(data[offset:offset+len(t.value)], t.value) {
+				return true
+			}
+			continue
+		}
+		matched := true
+		for i, v := range t.value {
+			if data[offset+i]&t.mask[i] != v&t.mask[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// allOf matches if all of its matchers match.
+type allOf []matcher
+
+func (a allOf) match(data []byte) bool {
+	for _, m := range a {
+		if !m.match(data) {
+			return false
+		}
+	}
+	return true
+}
+
+// anyOf matches if any of its matchers matches.
+type anyOf []matcher
+
+func (a anyOf) match(data []byte) bool {
+	for _, m := range a {
+		if m.match(data) {
+			return true
+		}
+	}
+	return false
+}
+
+// signatureFile is the JSON form of a set of signatures.
+type signatureFile struct {
+	Signatures []struct {
+		Type     string          `json:"type"`
+		Priority *int            `json:"priority"`
+		Match    json.RawMessage `json:"match"`
+	} `json:"signatures"`
+}
+
+// signatureRule is the JSON form of a rule. A rule either tests the content
+// or combines other rules with "all" or "any".
+type signatureRule struct {
+	All []json.RawMessage `json:"all"`
+	Any []json.RawMessage `json:"any"`
+
+	Offset json.RawMessage `json:"offset"`
+	Mask   json.RawMessage `json:"mask"`
+
+	String   *string `json:"string"`
+	Hex      *string `json:"hex"`
+	Byte     *uint64 `json:"byte"`
+	Uint16BE *uint64 `json:"uint16be"`
+	Uint16LE *uint64 `json:"uint16le"`
+	Uint32BE *uint64 `json:"uint32be"`
+	Uint32LE *uint64 `json:"uint32le"`
+}
+
+// ParseSignatures parses signature definitions in JSON. A definition names a
+// media type, an optional priority and a rule:
+//
+//	{
+//	  "signatures": [
+//	    {
+//	      "type": "application/vnd.acme.telemetry",
+//	      "priority": 60,
+//	      "match": {
+//	        "all": [
+//	          {"offset": 0, "string": "ACME"},
+//	          {"offset": [4, 16], "uint16le": 2},
+//	          {"any": [{"offset": 8, "hex": "cafe"}, {"offset": 8, "hex": "ba00", "mask": "ff00"}]}
+//	        ]
+//	      }
+//	    }
+//	  ]
+//	}
+//
+// A test rule has an offset, or an inclusive range of offsets to search, of
+// at most MaxSignatureOffset, and one value: a "string", "hex" bytes, or a
+// "byte", "uint16be", "uint16le", "uint32be" or "uint32le" number. An
+// optional "mask" is ANDed with the content and the value before they are
+// compared; it is given in hex for strings and bytes, and as a number for
+// numbers. Rules are combined with "all" and "any", which nest. Invalid
+// definitions are reported as a *SignatureError that locates the rule.
+func ParseSignatures(r io.Reader) (*Signatures, error) {
+	var file signatureFile
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&file); err != nil {
+		return nil, fmt.Errorf("mediatypes: invalid signatures: %v", err)
+	}
+
+	s := &Signatures{}
+	for i, def := range file.Signatures {
+		path := fmt.Sprintf("signatures[%d]", i)
+		name, _, err := parseMediaTypeName(def.Type)
+		if err != nil {
+			return nil, &SignatureError{Path: path + ".type", Message: err.Error()}
+		}
+		priority := DefaultPriority
+		if def.Priority != nil {
+			priority = *def.Priority
+			if priority < 0 || priority > 100 {
+				return nil, &SignatureError{Path: path + ".priority", Message: "must be between 0 and 100"}
+			}
+		}
+		if len(def.Match) == 0 {
+			return nil, &SignatureError{Path: path + ".match", Message: "missing rule"}
+		}
+		m, err := compileRule(path+".match", def.Match)
+		if err != nil {
+			return nil, err
+		}
+		s.list = append(s.list, signature{name: name, priority: priority, matcher: m})
+	}
+	return s, nil
+}
+
+// compileRule compiles the JSON form of a rule.
+func compileRule(path string, raw json.RawMessage) (matcher, error) {
+	var rule signatureRule
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&rule); err != nil {
+		return nil, &SignatureError{Path: path, Message: err.Error()}
+	}
+
+	if rule.All != nil || rule.Any != nil {
+		if rule.All != nil && rule.Any != nil {
+			return nil, &SignatureError{Path: path, Message: `"all" and "any" cannot be combined`}
+		}
+		if rule.Offset != nil || rule.Mask != nil || hasValue(rule) {
+			return nil, &SignatureError{Path: path, Message: `"all" and "any" cannot be combined with a test`}
+		}
+		key, raws := "all", rule.All
+		if rule.Any != nil {
+			key, raws = "any", rule.Any
+		}
+		if len(raws) == 0 {
+			return nil, &SignatureError{Path: path + "." + key, Message: "empty list"}
+		}
+		matchers := make([]matcher, len(raws))
+		for i, r := range raws {
+			m, err := compileRule(fmt.Sprintf("%s.%s[%d]", path, key, i), r)
+			if err != nil {
+				return nil, err
+			}
+			matchers[i] = m
+		}
+		if key == "all" {
+			return allOf(matchers), nil
+		}
+		return anyOf(matchers), nil
+	}
+
+	t := &byteTest{}
+	if err := parseOffset(rule.Offset, t); err != nil {
+		return nil, &SignatureError{Path: path + ".offset", Message: err.Error()}
+	}
+
+	var (
+		values    int
+		numeric   bool
+		size      int
+		order     binary.ByteOrder = binary.BigEndian
+		number    uint64
+		valueName string
+	)
+	if rule.String != nil {
+		values++
+		valueName = "string"
+		t.value = []byte(*rule.String)
+	}
+	if rule.Hex != nil {
+		values++
+		valueName = "hex"
+		v, err := hex.DecodeString(strings.Replace(*rule.Hex, " ", "", -1))
+		if err != nil {
+			return nil, &SignatureError{Path: path + ".hex", Message: err.Error()}
+		}
+		t.value = v
+	}
+	for _, n := range []struct {
+		name  string
+		value *uint64
+		size  int
+		order binary.ByteOrder
+	}{
+		{"byte", rule.Byte, 1, binary.BigEndian},
+		{"uint16be", rule.Uint16BE, 2, binary.BigEndian},
+		{"uint16le", rule.Uint16LE, 2, binary.LittleEndian},
+		{"uint32be", rule.Uint32BE, 4, binary.BigEndian},
+		{"uint32le", rule.Uint32LE, 4, binary.LittleEndian},
+	} {
+		if n.value != nil {
+			values++
+			valueName = n.name
+			numeric, size, order, number = true, n.size, n.order, *n.value
+		}
+	}
+	switch {
+	case values == 0:
+		return nil, &SignatureError{Path: path, Message: "missing value"}
+	case values > 1:
+		return nil, &SignatureError{Path: path, Message: "more than one value"}
+	}
+
+	if numeric {
+		if size < 8 && number >= 1<<(8*uint(size)) {
+			return nil, &SignatureError{Path: path + "." + valueName, Message: fmt.Sprintf("%d does not fit in %d bytes", number, size)}
+		}
+		t.value = encodeUint(number, size, order)
+		if rule.Mask != nil {
+			var mask uint64
+			if err := json.Unmarshal(rule.Mask, &mask); err != nil {
+				return nil, &SignatureError{Path: path + ".mask", Message: "must be a number for " + valueName}
+			}
+			t.mask = encodeUint(mask, size, order)
+		}
+		return t, nil
+	}
+
+	if len(t.value) == 0 {
+		return nil, &SignatureError{Path: path + "." + valueName, Message: "empty value"}
+	}
+	if rule.Mask != nil {
+		var mask string
+		if err := json.Unmarshal(rule.Mask, &mask); err != nil {
+			return nil, &SignatureError{Path: path + ".mask", Message: "must be a hex string for " + valueName}
+		}
+		m, err := hex.DecodeString(strings.Replace(mask, " ", "", -1))
+		if err != nil {
+			return nil, &SignatureError{Path: path + ".mask", Message: err.Error()}
+		}
+		if len(m) != len(t.value) {
+			return nil, &SignatureError{
+				Path:    path + ".mask",
+				Message: fmt.Sprintf("mask has %d bytes, value has %d", len(m), len(t.value)),
+			}
+		}
+		t.mask = m
+	}
+	return t, nil
+}
+
+// hasValue returns true if the rule has a test value.
+func hasValue(r signatureRule) bool {
+	return r.String != nil || r.Hex != nil || r.Byte != nil || r.Uint16BE != nil ||
+		r.Uint16LE != nil || r.Uint32BE != nil || r.Uint32LE != nil
+}
+
+// parseOffset parses an offset, which is a number or a [from, to] range.
+func parseOffset(raw json.RawMessage, t *byteTest) error {
+	if raw == nil {
+		return fmt.Errorf("missing offset")
+	}
+	var offset int
+	if err := json.Unmarshal(raw, &offset); err == nil {
+		if offset < 0 {
+			return fmt.Errorf("must not be negative")
+		}
+		if offset > MaxSignatureOffset {
+			return fmt.Errorf("must not exceed %d", MaxSignatureOffset)
+		}
+		t.from, t.to = offset, offset
+		return nil
+	}
+	var r []int
+	if err := json.Unmarshal(raw, &r); err != nil || len(r) != 2 {
+		return fmt.Errorf("must be a number or a [from, to] range")
+	}
+	if r[0] < 0 || r[1] < r[0] || r[1] > MaxSignatureOffset {
+		return fmt.Errorf("invalid range [%d, %d]", r[0], r[1])
+	}
+	t.from, t.to = r[0], r[1]
+	return nil
+}
+
+// encodeUint encodes a number of the given size in bytes.
+func encodeUint(n uint64, size int, order binary.ByteOrder) []byte {
+	b := make([]byte, 8)
+	switch size {
+	case 1:
+		return []byte{byte(n)}
+	case 2:
+		order.PutUint16(b, uint16(n))
+	case 4:
+		order.PutUint32(b, uint32(n))
+	default:
+		order.PutUint64(b, n)
+	}
+	return b[:size]
+}
+
+// parseMediaTypeName validates a media type such as "image/png" and returns
+// its lower-cased name and its parameters.
+func parseMediaTypeName(s string) (string, map[string]string, error) {
+	name, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return "", nil, err
+	}
+	if strings.IndexByte(name, '/') < 0 {
+		return "", nil, fmt.Errorf("%s is not a media type", strconv.Quote(s))
+	}
+	return name, params, nil
+}
+
+// customSignatures holds the signatures added with AddSignatures, highest
+// priority first.
+var customSignatures = struct {
+	sync.RWMutex
+	list []signature
+}{}
+
+// AddSignatures adds signatures to the detector used by Detect and
+// DetectReaderAt. A nil set adds nothing. AddSignatures is safe to call
+// concurrently with detection.
+func AddSignatures(s *Signatures) {
+	if s == nil {
+		return
+	}
+	customSignatures.Lock()
+	defer customSignatures.Unlock()
+	list := append(append([]signature(nil), customSignatures.list...), s.list...)
+	sort.SliceStable(
+		list, func(i, j int) bool {
+			return list[i].priority > list[j].priority
+		},
+	)
+	customSignatures.list = list
+}
+
+// LoadSignatures parses signature definitions in JSON, as described for
+// ParseSignatures, and adds them to the detector.
+func LoadSignatures(r io.Reader) error {
+	s, err := ParseSignatures(r)
+	if err != nil {
+		return err
+	}
+	AddSignatures(s)
+	return nil
+}
+
+// matchCustomSignatures returns the media type of the highest priority
+// custom signature that matches data and whose priority is in [min, max].
+func matchCustomSignatures(data []byte, min, max int) (MediaType, bool) {
+	customSignatures.RLock()
+	list := customSignatures.list
+	customSignatures.RUnlock()
+	for _, s := range list {
+		if s.priority >= min && s.priority <= max && s.matcher.match(data) {
+			return lookup(s.name), true
+		}
+	}
+	return MediaType{}, false
+}
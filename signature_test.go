@@ -0,0 +1,147 @@
+package mediatypes
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSignatures = `{
+  "signatures": [
+    {
+      "type": "application/vnd.acme.telemetry",
+      "priority": 60,
+      "match": {
+        "all": [
+          {"offset": 0, "string": "ACME"},
+          {"offset": [4, 8], "uint16le": 2},
+          {"any": [{"offset": 12, "hex": "ca fe"}, {"offset": 12, "hex": "ba00", "mask": "ff00"}]}
+        ]
+      }
+    },
+    {
+      "type": "application/vnd.acme.log",
+      "priority": 10,
+      "match": {"offset": 0, "uint32be": 1296122959, "mask": 4294967040}
+    }
+  ]
+}`
+
+func TestParseSignatures(t *testing.T) {
+	s, err := ParseSignatures(strings.NewReader(testSignatures))
+	if err != nil {
+		t.Fatalf("ParseSignatures() error = %v", err)
+	}
+	if s.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", s.Len())
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "first alternative", data: "ACME\x00\x00\x02\x00\x00\x00\x00\x00\xca\xfe", want: "application/vnd.acme.telemetry"},
+		{name: "masked alternative", data: "ACME\x00\x00\x00\x00\x02\x00\x00\x00\xba\x42", want: "application/vnd.acme.telemetry"},
+		{name: "offset out of range", data: "ACME\x00\x00\x00\x00\x00\x02\x00\x00\xca\xfe", want: ""},
+		{name: "masked number", data: "MADL\x00", want: "application/vnd.acme.log"},
+		{name: "no match", data: "ACMX", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var got string
+				for _, sig := range s.list {
+					if sig.matcher.match([]byte(tt.data)) {
+						got = sig.name
+						break
+					}
+				}
+				if got != tt.want {
+					t.Errorf("match = %q, want %q", got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestParseSignatures_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantPath string
+	}{
+		{name: "bad type", data: `{"signatures": [{"type": "acme", "match": {"offset": 0, "string": "A"}}]}`, wantPath: "signatures[0].type"},
+		{name: "bad priority", data: `{"signatures": [{"type": "a/b", "priority": 101, "match": {"offset": 0, "string": "A"}}]}`, wantPath: "signatures[0].priority"},
+		{name: "missing rule", data: `{"signatures": [{"type": "a/b"}]}`, wantPath: "signatures[0].match"},
+		{name: "missing offset", data: `{"signatures": [{"type": "a/b", "match": {"string": "A"}}]}`, wantPath: "signatures[0].match.offset"},
+		{name: "offset too large", data: `{"signatures": [{"type": "a/b", "match": {"offset": 9223372036854775807, "string": "A"}}]}`, wantPath: "signatures[0].match.offset"},
+		{name: "range too large", data: `{"signatures": [{"type": "a/b", "match": {"offset": [0, 9223372036854775807], "string": "A"}}]}`, wantPath: "signatures[0].match.offset"},
+		{name: "bad range", data: `{"signatures": [{"type": "a/b", "match": {"offset": [4, 2], "string": "A"}}]}`, wantPath: "signatures[0].match.offset"},
+		{name: "two values", data: `{"signatures": [{"type": "a/b", "match": {"offset": 0, "string": "A", "byte": 1}}]}`, wantPath: "signatures[0].match"},
+		{name: "bad hex", data: `{"signatures": [{"type": "a/b", "match": {"offset": 0, "hex": "zz"}}]}`, wantPath: "signatures[0].match.hex"},
+		{name: "number too large", data: `{"signatures": [{"type": "a/b", "match": {"offset": 0, "uint16be": 70000}}]}`, wantPath: "signatures[0].match.uint16be"},
+		{
+			name:     "nested mask length",
+			data:     `{"signatures": [{"type": "a/b", "match": {"all": [{"offset": 0, "string": "A"}, {"any": [{"offset": 1, "hex": "0102", "mask": "ff"}]}]}}]}`,
+			wantPath: "signatures[0].match.all[1].any[0].mask",
+		},
+		{name: "unknown field", data: `{"signatures": [{"type": "a/b", "match": {"offset": 0, "strnig": "A"}}]}`, wantPath: "signatures[0].match"},
+		{name: "empty list", data: `{"signatures": [{"type": "a/b", "match": {"any": []}}]}`, wantPath: "signatures[0].match.any"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, err := ParseSignatures(strings.NewReader(tt.data))
+				serr, ok := err.(*SignatureError)
+				if !ok {
+					t.Fatalf("ParseSignatures() error = %v, want *SignatureError", err)
+				}
+				if serr.Path != tt.wantPath {
+					t.Errorf("SignatureError.Path = %q, want %q (%v)", serr.Path, tt.wantPath, serr)
+				}
+			},
+		)
+	}
+}
+
+func TestLoadSignatures(t *testing.T) {
+	defer func(saved []signature) {
+		customSignatures.Lock()
+		customSignatures.list = saved
+		customSignatures.Unlock()
+	}(customSignatures.list)
+
+	if err := LoadSignatures(strings.NewReader(testSignatures)); err != nil {
+		t.Fatalf("LoadSignatures() error = %v", err)
+	}
+	if got := Detect([]byte("ACME\x00\x00\x02\x00\x00\x00\x00\x00\xca\xfe")); got.Name() != "application/vnd.acme.telemetry" {
+		t.Errorf("Detect() = %s, want application/vnd.acme.telemetry", got.Name())
+	}
+	if got := Detect([]byte("MADL\x00\x01")); got.Name() != "application/vnd.acme.log" {
+		t.Errorf("Detect() = %s, want application/vnd.acme.log", got.Name())
+	}
+	if got := Detect([]byte("GIF89a")); got.Name() != "image/gif" {
+		t.Errorf("Detect() = %s, want image/gif", got.Name())
+	}
+}
+
+func TestAddSignatures(t *testing.T) {
+	defer func(saved []signature) {
+		customSignatures.Lock()
+		customSignatures.list = saved
+		customSignatures.Unlock()
+	}(customSignatures.list)
+
+	AddSignatures(nil)
+	s, err := ParseSignatures(strings.NewReader(`{"signatures": [{"type": "a/b", "match": {"offset": [0, 1048576], "string": "ZZ"}}]}`))
+	if err != nil {
+		t.Fatalf("ParseSignatures() error = %v", err)
+	}
+	AddSignatures(s)
+	if got := Detect([]byte("GIF89a")); got.Name() != "image/gif" {
+		t.Errorf("Detect() = %s, want image/gif", got.Name())
+	}
+	if got := Detect([]byte("xZZ")); got.Name() != "a/b" {
+		t.Errorf("Detect() = %s, want a/b", got.Name())
+	}
+}
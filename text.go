@@ -0,0 +1,401 @@
+package mediatypes
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TextClass is a candidate media type for text, as suggested by one of the
+// text classifiers.
+type TextClass struct {
+	// MediaType is the suggested media type.
+	MediaType MediaType
+
+	// Confidence is how confident the classifier is, between 0 and 1.
+	Confidence float64
+
+	// Reason names the evidence the classifier found, such as "shebang",
+	// "modeline", "front matter", "delimiter", "yaml" or "ini".
+	Reason string
+}
+
+// minTextConfidence is the confidence Detect requires before it reports a
+// text classification instead of "text/plain".
+const minTextConfidence = 0.6
+
+// ClassifyText applies heuristic classifiers to text and returns the
+// candidate media types, most confident first. It recognizes shebang lines
+// such as "#!/usr/bin/env python", vim and Emacs modelines, Markdown front
+// matter, CSV and TSV by the consistency of their delimiters, YAML and INI.
+// INI files have no media type of their own in the registry and are reported
+// as "text/plain". The text must be UTF-8; a prefix of a larger text can be
+// passed.
+func ClassifyText(data []byte) []TextClass {
+	return classifyText(data, false)
+}
+
+// classifyText implements ClassifyText. If complete is set, data holds the
+// whole text rather than a prefix.
+func classifyText(data []byte, complete bool) []TextClass {
+	lines := textLines(data, complete)
+	var result []TextClass
+	add := func(name string, confidence float64, reason string) {
+		if m, ok := ByName(name); ok && confidence > 0 {
+			result = append(result, TextClass{MediaType: m, Confidence: confidence, Reason: reason})
+		}
+	}
+
+	if name, ok := classifyShebang(lines); ok {
+		add(name, 0.95, "shebang")
+	}
+	if name, ok := classifyModeline(lines); ok {
+		add(name, 0.9, "modeline")
+	}
+	if classifyFrontMatter(lines) {
+		add("text/markdown", 0.8, "front matter")
+	}
+	if name, confidence := classifyDelimited(lines); name != "" {
+		add(name, confidence, "delimiter")
+	}
+	add("text/x-yaml", classifyYAML(lines), "yaml")
+	add("text/plain", classifyINI(lines), "ini")
+
+	sort.SliceStable(
+		result, func(i, j int) bool {
+			return result[i].Confidence > result[j].Confidence
+		},
+	)
+	return result
+}
+
+// textLines splits text into lines. If the text is a prefix that does not
+// end with a line break, the last line may be cut short and is dropped,
+// unless it is the only one.
+func textLines(data []byte, complete bool) []string {
+	text := strings.Replace(string(data), "\r\n", "\n", -1)
+	lines := strings.Split(text, "\n")
+	if len(lines) > 1 && (!complete || lines[len(lines)-1] == "") {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// interpreters maps the interpreters named in shebang lines to media type
+// names.
+var interpreters = map[string]string{
+	"bash":    "text/x-sh",
+	"csh":     "text/x-csh",
+	"dash":    "text/x-sh",
+	"guile":   "text/x-script.guile",
+	"ksh":     "text/x-script.ksh",
+	"lua":     "text/x-lua",
+	"make":    "text/x-makefile",
+	"node":    "text/javascript",
+	"nodejs":  "text/javascript",
+	"perl":    "text/x-perl",
+	"php":     "application/x-httpd-php",
+	"python":  "text/x-python",
+	"python2": "text/x-python",
+	"python3": "text/x-python",
+	"rexx":    "text/x-script.rexx",
+	"sh":      "text/x-sh",
+	"tclsh":   "text/x-tcl",
+	"tcsh":    "text/x-script.tcsh",
+	"wish":    "text/x-tcl",
+	"zsh":     "text/x-script.zsh",
+}
+
+// classifyShebang returns the media type named by the interpreter in a
+// shebang line, such as "#!/bin/sh" or "#!/usr/bin/env python3".
+func classifyShebang(lines []string) (string, bool) {
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "#!") {
+		return "", false
+	}
+	fields := strings.Fields(lines[0][2:])
+	if len(fields) == 0 {
+		return "", false
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// Skip options such as "-S" to find the command.
+		interpreter = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interpreter = path.Base(f)
+				break
+			}
+		}
+	}
+	// Drop version numbers such as in "python3.11" or "perl5".
+	name, ok := interpreters[interpreter]
+	if !ok {
+		name, ok = interpreters[strings.TrimRight(interpreter, "0123456789.")]
+	}
+	return name, ok
+}
+
+// fileTypes maps the file types named in vim and Emacs modelines to media
+// type names.
+var fileTypes = map[string]string{
+	"asm":        "text/x-asm",
+	"bash":       "text/x-sh",
+	"c":          "text/x-c",
+	"c++":        "text/x-c++src",
+	"cpp":        "text/x-c++src",
+	"css":        "text/css",
+	"fortran":    "text/x-fortran",
+	"html":       "text/html",
+	"java":       "text/x-java-source",
+	"javascript": "text/javascript",
+	"js":         "text/javascript",
+	"latex":      "text/x-tex",
+	"lua":        "text/x-lua",
+	"make":       "text/x-makefile",
+	"makefile":   "text/x-makefile",
+	"markdown":   "text/markdown",
+	"pascal":     "text/x-pascal",
+	"perl":       "text/x-perl",
+	"python":     "text/x-python",
+	"sh":         "text/x-sh",
+	"shell":      "text/x-sh",
+	"tcl":        "text/x-tcl",
+	"tex":        "text/x-tex",
+	"yaml":       "text/x-yaml",
+	"zsh":        "text/x-script.zsh",
+}
+
+var (
+	// vimModeline matches a vim modeline that sets the file type, such as
+	// "vim: set ft=python:" or "vi: syntax=sh".
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex)(?:[<=>]?\d+)?:.*?\b(?:ft|filetype|syntax)=([A-Za-z0-9_+-]+)`)
+
+	// emacsModeline matches an Emacs modeline, such as "-*- python -*-" or
+	// "-*- mode: python; coding: utf-8 -*-".
+	emacsModeline = regexp.MustCompile(`-\*-\s*(.*?)\s*-\*-`)
+)
+
+// classifyModeline returns the media type named by a vim or Emacs modeline
+// in the first or last five lines.
+func classifyModeline(lines []string) (string, bool) {
+	candidates := lines
+	if len(lines) > 10 {
+		candidates = append(append([]string(nil), lines[:5]...), lines[len(lines)-5:]...)
+	}
+	for _, line := range candidates {
+		var mode string
+		if m := vimModeline.FindStringSubmatch(line); m != nil {
+			mode = m[1]
+		} else if m := emacsModeline.FindStringSubmatch(line); m != nil {
+			mode = m[1]
+			if strings.Contains(mode, ":") {
+				mode = ""
+				for _, variable := range strings.Split(m[1], ";") {
+					kv := strings.SplitN(variable, ":", 2)
+					if len(kv) == 2 && strings.TrimSpace(kv[0]) == "mode" {
+						mode = strings.TrimSpace(kv[1])
+					}
+				}
+			}
+		}
+		if name, ok := fileTypes[strings.TrimSuffix(strings.ToLower(mode), "-mode")]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// classifyFrontMatter returns true if the text starts with YAML or TOML
+// front matter, as used by Markdown documents in static site generators.
+func classifyFrontMatter(lines []string) bool {
+	if len(lines) < 3 {
+		return false
+	}
+	fence := strings.TrimSpace(lines[0])
+	if fence != "---" && fence != "+++" {
+		return false
+	}
+	for i, line := range lines[1:] {
+		if strings.TrimSpace(line) == fence {
+			// Front matter must be followed by content.
+			return i > 0 && i+2 < len(lines)
+		}
+	}
+	return false
+}
+
+// classifyDelimited returns "text/csv" or "text/tab-separated-values" and a
+// confidence if the lines have a consistent number of delimited fields. At
+// least three records are required, since two lines of prose often have a
+// comma each.
+func classifyDelimited(lines []string) (string, float64) {
+	var nonEmpty []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			nonEmpty = append(nonEmpty, line)
+		}
+	}
+	if len(nonEmpty) < 3 {
+		return "", 0
+	}
+
+	var (
+		bestName       string
+		bestConfidence float64
+	)
+	for _, d := range []struct {
+		delimiter byte
+		name      string
+	}{
+		{delimiter: '\t', name: "text/tab-separated-values"},
+		{delimiter: ',', name: "text/csv"},
+		{delimiter: ';', name: "text/csv"},
+	} {
+		counts := make(map[int]int)
+		for _, line := range nonEmpty {
+			// A line supports a number of fields only if at least two of
+			// them have content, as in "a,b" but not "x = 1;".
+			if fields, filled := countFields(line, d.delimiter); filled >= 2 {
+				counts[fields]++
+			}
+		}
+		fields, lines := 0, 0
+		for f, n := range counts {
+			if n > lines || (n == lines && f > fields) {
+				fields, lines = f, n
+			}
+		}
+		confidence := float64(lines) / float64(len(nonEmpty))
+		if fields < 2 || confidence < 0.8 {
+			continue
+		}
+		confidence *= 0.9
+		if confidence > bestConfidence {
+			bestName, bestConfidence = d.name, confidence
+		}
+	}
+	return bestName, bestConfidence
+}
+
+// countFields returns the number of fields in a delimited line, not counting
+// empty trailing fields, and how many of them are not blank. Delimiters inside
+// double quotes are ignored.
+func countFields(line string, delimiter byte) (int, int) {
+	var (
+		fields, filled, last int
+		quoted, blank        = false, true
+	)
+	for i := 0; i <= len(line); i++ {
+		if i < len(line) {
+			switch c := line[i]; {
+			case c == '"':
+				quoted = !quoted
+				blank = false
+				continue
+			case c != delimiter || quoted:
+				if c != ' ' && c != '\t' {
+					blank = false
+				}
+				continue
+			}
+		}
+		// The end of a field.
+		fields++
+		if !blank {
+			filled++
+			last = fields
+		}
+		blank = true
+	}
+	return last, filled
+}
+
+// classifyYAML returns the confidence that the lines are a YAML document.
+func classifyYAML(lines []string) float64 {
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "%YAML") {
+		return 0.9
+	}
+	var keys, matching, total int
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case trimmed == "---" || trimmed == "...":
+			matching++
+		case isYAMLKey(trimmed):
+			keys++
+			matching++
+		case strings.HasPrefix(trimmed, "- ") || trimmed == "-":
+			matching++
+		case line[0] == ' ':
+			// A continuation of a block scalar or flow collection.
+			continue
+		}
+		total++
+	}
+	if keys < 2 || total == 0 {
+		return 0
+	}
+	ratio := float64(matching) / float64(total)
+	if ratio < 0.9 {
+		return 0
+	}
+	return 0.7 * ratio
+}
+
+// isYAMLKey returns true if the line starts with a mapping key, such as
+// "name: value" or "- name:".
+func isYAMLKey(line string) bool {
+	line = strings.TrimPrefix(line, "- ")
+	i := strings.Index(line, ":")
+	if i <= 0 || (i+1 < len(line) && line[i+1] != ' ') {
+		return false
+	}
+	key := line[:i]
+	if key[0] == '"' || key[0] == '\'' {
+		return len(key) > 1 && key[len(key)-1] == key[0]
+	}
+	return !strings.ContainsAny(key, " \t{}[],=")
+}
+
+// classifyINI returns the confidence that the lines are an INI file.
+func classifyINI(lines []string) float64 {
+	var sections, keys, total int
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#':
+			continue
+		case trimmed[0] == '[' && trimmed[len(trimmed)-1] == ']' && !strings.ContainsAny(trimmed[1:len(trimmed)-1], "[],"):
+			sections++
+		case strings.Contains(trimmed, "=") && !strings.ContainsAny(trimmed[:strings.Index(trimmed, "=")], "\"'<>(){}"):
+			keys++
+		}
+		total++
+	}
+	if total == 0 || sections+keys < total || keys == 0 {
+		return 0
+	}
+	if sections == 0 {
+		// Without sections this may just as well be a properties or env file.
+		return 0.4
+	}
+	return 0.8
+}
+
+// classifyTextBytes returns the most confident text classification of data,
+// if any reaches minTextConfidence. If complete is set, data holds the whole
+// text rather than a prefix.
+func classifyTextBytes(data []byte, complete bool) (MediaType, bool) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return MediaType{}, false
+	}
+	classes := classifyText(data, complete)
+	if len(classes) == 0 || classes[0].Confidence < minTextConfidence {
+		return MediaType{}, false
+	}
+	return classes[0].MediaType, true
+}
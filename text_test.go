@@ -0,0 +1,99 @@
+package mediatypes
+
+import (
+	"testing"
+)
+
+func TestClassifyText(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		want       string
+		wantReason string
+	}{
+		{name: "env shebang", data: "#!/usr/bin/env python3\nprint('hi')\n", want: "text/x-python", wantReason: "shebang"},
+		{name: "env shebang with options", data: "#!/usr/bin/env -S node --harmony\nconsole.log(1)\n", want: "text/javascript", wantReason: "shebang"},
+		{name: "shebang", data: "#!/bin/bash\necho hi\n", want: "text/x-sh", wantReason: "shebang"},
+		{name: "versioned shebang", data: "#!/usr/local/bin/perl5.36\nprint 1;\n", want: "text/x-perl", wantReason: "shebang"},
+		{name: "vim modeline", data: "x = 1\n# vim: set ft=python ts=4:\n", want: "text/x-python", wantReason: "modeline"},
+		{name: "emacs modeline", data: "/* -*- mode: c; coding: utf-8 -*- */\nint x;\n", want: "text/x-c", wantReason: "modeline"},
+		{name: "short emacs modeline", data: "# -*- perl -*-\nprint 1;\n", want: "text/x-perl", wantReason: "modeline"},
+		{name: "front matter", data: "---\ntitle: Hello\n---\n# Hello\n\nWorld\n", want: "text/markdown", wantReason: "front matter"},
+		{name: "csv", data: "name,age,city\n\"Doe, Jane\",42,Paris\nJohn,7,Rome\n", want: "text/csv", wantReason: "delimiter"},
+		{name: "semicolon csv", data: "name;age\nJane;42\nJohn;7\n", want: "text/csv", wantReason: "delimiter"},
+		{name: "tsv", data: "name\tage\nJane\t42\nJohn\t7\n", want: "text/tab-separated-values", wantReason: "delimiter"},
+		{name: "yaml", data: "version: 2\nservices:\n  web:\n    image: nginx\n    ports:\n      - \"80:80\"\n", want: "text/x-yaml", wantReason: "yaml"},
+		{name: "yaml directive", data: "%YAML 1.2\n---\nfoo\n", want: "text/x-yaml", wantReason: "yaml"},
+		{name: "ini", data: "; comment\n[core]\nbare = false\n[remote \"origin\"]\nurl = x\n", want: "text/plain", wantReason: "ini"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got := ClassifyText([]byte(tt.data))
+				if len(got) == 0 {
+					t.Fatalf("ClassifyText() returned no classes, want %s", tt.want)
+				}
+				if got[0].MediaType.Name() != tt.want || got[0].Reason != tt.wantReason {
+					t.Errorf("ClassifyText()[0] = %s (%s), want %s (%s)", got[0].MediaType.Name(), got[0].Reason, tt.want, tt.wantReason)
+				}
+				if got[0].Confidence < minTextConfidence || got[0].Confidence > 1 {
+					t.Errorf("ClassifyText()[0].Confidence = %v", got[0].Confidence)
+				}
+			},
+		)
+	}
+}
+
+func TestClassifyText_Prose(t *testing.T) {
+	for _, data := range []string{
+		"Dear Jane,\n\nThank you for the letter. It arrived on time: as always.\nKind regards, John\n",
+		"Hello, my name is Bob.\nI live here, and I like it.\n",
+		"int a = 1;\nint b = 2;\nint c = 3;\nreturn a;\n",
+		"var x = 1;\nvar y = 2;\nconsole.log(x, y);\n",
+	} {
+		for _, c := range ClassifyText([]byte(data)) {
+			if c.Confidence >= minTextConfidence {
+				t.Errorf("ClassifyText(%q) = %s (%s, %v) for prose", data, c.MediaType.Name(), c.Reason, c.Confidence)
+			}
+		}
+	}
+}
+
+func TestTextClassifiers_Registered(t *testing.T) {
+	for interpreter, name := range interpreters {
+		if _, ok := ByName(name); !ok {
+			t.Errorf("interpreter %s maps to unknown media type %s", interpreter, name)
+		}
+	}
+	for fileType, name := range fileTypes {
+		if _, ok := ByName(name); !ok {
+			t.Errorf("file type %s maps to unknown media type %s", fileType, name)
+		}
+	}
+}
+
+func TestCountFields(t *testing.T) {
+	tests := []struct {
+		line       string
+		delimiter  byte
+		wantFields int
+		wantFilled int
+	}{
+		{line: "a,b,c", delimiter: ',', wantFields: 3, wantFilled: 3},
+		{line: "a,,c", delimiter: ',', wantFields: 3, wantFilled: 2},
+		{line: "a,b,", delimiter: ',', wantFields: 2, wantFilled: 2},
+		{line: "x = 1;", delimiter: ';', wantFields: 1, wantFilled: 1},
+		{line: `"Doe, Jane",42`, delimiter: ',', wantFields: 2, wantFilled: 2},
+		{line: "", delimiter: ',', wantFields: 0, wantFilled: 0},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.line, func(t *testing.T) {
+				fields, filled := countFields(tt.line, tt.delimiter)
+				if fields != tt.wantFields || filled != tt.wantFilled {
+					t.Errorf("countFields(%q) = %d, %d, want %d, %d", tt.line, fields, filled, tt.wantFields, tt.wantFilled)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,661 @@
+package mediatypes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+)
+
+// resourceHeaderLen is the number of bytes of a resource that the WHATWG MIME
+// Sniffing Standard considers.
+const resourceHeaderLen = 1445
+
+// SniffContext is the context in which a browser uses a resource, which
+// determines how the WHATWG MIME Sniffing Standard computes its media type.
+type SniffContext int
+
+const (
+	// ContextBrowsing is a resource loaded in a browsing context, such as a
+	// page or an iframe.
+	ContextBrowsing SniffContext = iota
+
+	// ContextImage is a resource loaded by an img element or as a CSS image.
+	ContextImage
+
+	// ContextAudioVideo is a resource loaded by an audio or video element.
+	ContextAudioVideo
+
+	// ContextPlugin is a resource loaded by an embed or object element.
+	ContextPlugin
+
+	// ContextStyle is a resource loaded as a style sheet.
+	ContextStyle
+
+	// ContextScript is a resource loaded as a script.
+	ContextScript
+
+	// ContextFont is a resource loaded as a font.
+	ContextFont
+
+	// ContextTextTrack is a resource loaded as a text track.
+	ContextTextTrack
+
+	// ContextCacheManifest is a resource loaded as a cache manifest.
+	ContextCacheManifest
+)
+
+// sniffOptions holds the options of SniffBrowser.
+type sniffOptions struct {
+	noSniff bool
+	context SniffContext
+}
+
+// SniffOption configures SniffBrowser.
+type SniffOption func(*sniffOptions)
+
+// WithNoSniff sets the no-sniff flag, as a response with the header
+// "X-Content-Type-Options: nosniff" does.
+func WithNoSniff() SniffOption {
+	return func(o *sniffOptions) {
+		o.noSniff = true
+	}
+}
+
+// WithSniffContext sets the context in which the resource is used. The
+// default is ContextBrowsing.
+func WithSniffContext(c SniffContext) SniffOption {
+	return func(o *sniffOptions) {
+		o.context = c
+	}
+}
+
+// SniffBrowser returns the media type a browser computes for a resource, as
+// described by the WHATWG MIME Sniffing Standard. contentType is the value of
+// the Content-Type header, or empty if there is none, and data holds the
+// first bytes of the resource; at most 1445 are considered. The result is an
+// empty MediaType if the standard leaves the media type undefined, which can
+// only happen outside of the browsing context.
+//
+// The Content-Type header is parsed as browsers parse it, not as Parse does:
+// malformed parameters are skipped, and the header is ignored only if its
+// type or subtype is invalid. MP3 audio without an ID3 tag is recognized
+// with the frame sizes the standard computes, which differ from those of the
+// MPEG specification, so, as in the standard, most MPEG-1 files are not.
+//
+// Unlike Detect, which recognizes as many formats as it can, SniffBrowser
+// predicts what a browser will do, so it can be used to find responses that a
+// browser would render as HTML despite their declared media type. Images,
+// audio and video are taken to be supported if they have a signature in the
+// standard's pattern tables.
+func SniffBrowser(contentType string, data []byte, opts ...SniffOption) MediaType {
+	var o sniffOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(data) > resourceHeaderLen {
+		data = data[:resourceHeaderLen]
+	}
+
+	supplied, defined := parseMIMEType(contentType)
+	essence := strings.ToLower(supplied.name)
+
+	switch o.context {
+	case ContextImage:
+		return sniffMediaContext(supplied, defined, data, imagePatterns, nil)
+	case ContextAudioVideo:
+		return sniffMediaContext(supplied, defined, data, audioVideoPatterns, matchAudioVideo)
+	case ContextFont:
+		return sniffMediaContext(supplied, defined, data, fontPatterns, nil)
+	case ContextPlugin:
+		if !defined {
+			return lookup("application/octet-stream")
+		}
+		return supplied
+	case ContextStyle, ContextScript:
+		return supplied
+	case ContextTextTrack:
+		return lookup("text/vtt")
+	case ContextCacheManifest:
+		return lookup("text/cache-manifest")
+	}
+
+	if !defined || essence == "unknown/unknown" || essence == "application/unknown" || essence == "*/*" {
+		return sniffUnknown(data, !o.noSniff)
+	}
+	if o.noSniff {
+		return supplied
+	}
+	if hasApacheBug(contentType) {
+		return sniffTextOrBinary(data)
+	}
+	if isXMLMIMEType(essence) {
+		return supplied
+	}
+	if essence == "text/html" {
+		return sniffFeedOrHTML(supplied, data)
+	}
+	if strings.HasPrefix(essence, "image/") && supportedBy(essence, imagePatterns) {
+		if m, ok := matchPatterns(data, imagePatterns); ok {
+			return m
+		}
+	}
+	if (strings.HasPrefix(essence, "audio/") || strings.HasPrefix(essence, "video/") || essence == "application/ogg") &&
+		supportedBy(essence, audioVideoPatterns) {
+		if m, ok := matchPatterns(data, audioVideoPatterns); ok {
+			return m
+		}
+		if m, ok := matchAudioVideo(data); ok {
+			return m
+		}
+	}
+	return supplied
+}
+
+// parseMIMEType parses a MIME type as the WHATWG MIME Sniffing Standard
+// does. It returns false if s has no valid type and subtype; parameters with
+// invalid names or values, and repeated parameters, are skipped.
+func parseMIMEType(s string) (MediaType, bool) {
+	s = strings.Trim(s, httpWhitespace)
+	slash := strings.IndexByte(s, '/')
+	if slash < 0 {
+		return MediaType{}, false
+	}
+	typ, rest := s[:slash], s[slash+1:]
+	subtype, rest := rest, ""
+	if i := strings.IndexByte(subtype, ';'); i >= 0 {
+		subtype, rest = subtype[:i], subtype[i:]
+	}
+	subtype = strings.TrimRight(subtype, httpWhitespace)
+	if !isToken(typ) || !isToken(subtype) {
+		return MediaType{}, false
+	}
+	m := lookup(strings.ToLower(typ + "/" + subtype))
+
+	for len(rest) > 0 {
+		// Skip the ";" and the whitespace that follows it.
+		rest = strings.TrimLeft(rest[1:], httpWhitespace)
+		end := strings.IndexAny(rest, ";=")
+		if end < 0 {
+			break
+		}
+		name := strings.ToLower(rest[:end])
+		if rest[end] == ';' {
+			rest = rest[end:]
+			continue
+		}
+		rest = rest[end+1:]
+		if rest == "" {
+			break
+		}
+
+		var value string
+		if rest[0] == '"' {
+			value, rest = collectQuotedString(rest)
+			if i := strings.IndexByte(rest, ';'); i >= 0 {
+				rest = rest[i:]
+			} else {
+				rest = ""
+			}
+		} else {
+			value, rest = rest, ""
+			if i := strings.IndexByte(value, ';'); i >= 0 {
+				value, rest = value[:i], value[i:]
+			}
+			value = strings.TrimRight(value, httpWhitespace)
+			if value == "" {
+				continue
+			}
+		}
+		if _, ok := m.params[name]; ok || !isToken(name) || !isQuotedStringValue(value) {
+			continue
+		}
+		m = m.WithParam(name, value)
+	}
+	return m, true
+}
+
+// httpWhitespace holds the HTTP whitespace bytes.
+const httpWhitespace = "\t\n\r "
+
+// collectQuotedString returns the value of the quoted string at the start of
+// s, which starts with a quote, and the rest of s. An unterminated string
+// runs to the end of s.
+func collectQuotedString(s string) (string, string) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), s[i+1:]
+		case '\\':
+			if i+1 == len(s) {
+				b.WriteByte('\\')
+				return b.String(), ""
+			}
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String(), ""
+}
+
+// isQuotedStringValue returns true if s consists of HTTP quoted-string token
+// code points: tab, visible ASCII, space and bytes from 0x80.
+func isQuotedStringValue(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c != '\t' && (c < 0x20 || c == 0x7f) {
+			return false
+		}
+	}
+	return true
+}
+
+// sniffMediaContext computes the media type of a resource used as an image,
+// audio, video or font: XML media types are kept, and otherwise the pattern
+// tables take precedence over the supplied media type.
+func sniffMediaContext(supplied MediaType, defined bool, data []byte, patterns []sniffPattern, extra func([]byte) (MediaType, bool)) MediaType {
+	if defined && isXMLMIMEType(strings.ToLower(supplied.name)) {
+		return supplied
+	}
+	if m, ok := matchPatterns(data, patterns); ok {
+		return m
+	}
+	if extra != nil {
+		if m, ok := extra(data); ok {
+			return m
+		}
+	}
+	return supplied
+}
+
+// hasApacheBug returns true if the Content-Type header has one of the values
+// that old versions of Apache sent for every file, in which case the declared
+// text/plain is not trusted.
+func hasApacheBug(contentType string) bool {
+	switch contentType {
+	case "text/plain", "text/plain; charset=ISO-8859-1", "text/plain; charset=iso-8859-1", "text/plain; charset=UTF-8":
+		return true
+	}
+	return false
+}
+
+// isXMLMIMEType returns true if the essence is an XML media type.
+func isXMLMIMEType(essence string) bool {
+	return strings.HasSuffix(essence, "+xml") || essence == "text/xml" || essence == "application/xml"
+}
+
+// sniffPattern is a row of a pattern table of the WHATWG MIME Sniffing
+// Standard. The content matches if, after skipping leading bytes in ignore,
+// its bytes ANDed with mask equal pattern. If terminated is set, the pattern
+// must be followed by a tag-terminating byte.
+type sniffPattern struct {
+	pattern    string
+	mask       string
+	ignore     string
+	terminated bool
+	name       string
+}
+
+// whitespaceBytes are the whitespace bytes of the standard.
+const whitespaceBytes = "\t\n\x0c\r "
+
+// htmlPattern returns a case-insensitive pattern for an HTML tag that may be
+// preceded by whitespace and must be followed by a tag-terminating byte.
+func htmlPattern(tag string) sniffPattern {
+	mask := make([]byte, len(tag))
+	for i := 0; i < len(tag); i++ {
+		mask[i] = 0xff
+		if tag[i] >= 'A' && tag[i] <= 'Z' {
+			mask[i] = 0xdf
+		}
+	}
+	return sniffPattern{pattern: tag, mask: string(mask), ignore: whitespaceBytes, terminated: true, name: "text/html"}
+}
+
+// scriptablePatterns are the patterns of the types that may run script,
+// which are only sniffed if the no-sniff flag is not set.
+var scriptablePatterns = []sniffPattern{
+	htmlPattern("<!DOCTYPE HTML"),
+	htmlPattern("<HTML"),
+	htmlPattern("<HEAD"),
+	htmlPattern("<SCRIPT"),
+	htmlPattern("<IFRAME"),
+	htmlPattern("<H1"),
+	htmlPattern("<DIV"),
+	htmlPattern("<FONT"),
+	htmlPattern("<TABLE"),
+	htmlPattern("<A"),
+	htmlPattern("<STYLE"),
+	htmlPattern("<TITLE"),
+	htmlPattern("<B"),
+	htmlPattern("<BODY"),
+	htmlPattern("<BR"),
+	htmlPattern("<P"),
+	htmlPattern("<!--"),
+	{pattern: "<?xml", ignore: whitespaceBytes, name: "text/xml"},
+	{pattern: "%PDF-", name: "application/pdf"},
+}
+
+// unknownPatterns are the other patterns of the rules for identifying an
+// unknown media type.
+var unknownPatterns = []sniffPattern{
+	{pattern: "%!PS-Adobe-", name: "application/postscript"},
+	{pattern: "\xfe\xff\x00\x00", mask: "\xff\xff\x00\x00", name: "text/plain"},
+	{pattern: "\xff\xfe\x00\x00", mask: "\xff\xff\x00\x00", name: "text/plain"},
+	{pattern: "\xef\xbb\xbf\x00", mask: "\xff\xff\xff\x00", name: "text/plain"},
+}
+
+// imagePatterns is the image type pattern table.
+var imagePatterns = []sniffPattern{
+	{pattern: "\x00\x00\x01\x00", name: "image/x-icon"},
+	{pattern: "\x00\x00\x02\x00", name: "image/x-icon"},
+	{pattern: "BM", name: "image/bmp"},
+	{pattern: "GIF87a", name: "image/gif"},
+	{pattern: "GIF89a", name: "image/gif"},
+	{pattern: "RIFF\x00\x00\x00\x00WEBPVP", mask: "\xff\xff\xff\xff\x00\x00\x00\x00\xff\xff\xff\xff\xff\xff", name: "image/webp"},
+	{pattern: "\x89PNG\r\n\x1a\n", name: "image/png"},
+	{pattern: "\xff\xd8\xff", name: "image/jpeg"},
+}
+
+// audioVideoPatterns is the audio or video type pattern table. MP4 and WebM
+// are recognized by matchAudioVideo; their rows have no pattern and only
+// mark the media types as supported.
+var audioVideoPatterns = []sniffPattern{
+	{pattern: "FORM\x00\x00\x00\x00AIFF", mask: "\xff\xff\xff\xff\x00\x00\x00\x00\xff\xff\xff\xff", name: "audio/aiff"},
+	{pattern: "ID3", name: "audio/mpeg"},
+	{pattern: "OggS\x00", name: "application/ogg"},
+	{pattern: "MThd\x00\x00\x00\x06", name: "audio/midi"},
+	{pattern: "RIFF\x00\x00\x00\x00AVI ", mask: "\xff\xff\xff\xff\x00\x00\x00\x00\xff\xff\xff\xff", name: "video/avi"},
+	{pattern: "RIFF\x00\x00\x00\x00WAVE", mask: "\xff\xff\xff\xff\x00\x00\x00\x00\xff\xff\xff\xff", name: "audio/wave"},
+	{name: "video/mp4"},
+	{name: "video/webm"},
+}
+
+// fontPatterns is the font type pattern table.
+var fontPatterns = []sniffPattern{
+	{pattern: strings.Repeat("\x00", 34) + "LP", mask: strings.Repeat("\x00", 34) + "\xff\xff", name: "application/vnd.ms-fontobject"},
+	{pattern: "\x00\x01\x00\x00", name: "font/ttf"},
+	{pattern: "OTTO", name: "font/otf"},
+	{pattern: "ttcf", name: "font/collection"},
+	{pattern: "wOFF", name: "font/woff"},
+	{pattern: "wOF2", name: "font/woff2"},
+}
+
+// archivePatterns is the archive type pattern table.
+var archivePatterns = []sniffPattern{
+	{pattern: "\x1f\x8b\x08", name: "application/x-gzip"},
+	{pattern: "PK\x03\x04", name: "application/zip"},
+	{pattern: "Rar!\x1a\x07\x00", name: "application/x-rar-compressed"},
+}
+
+// match returns true if data matches the pattern.
+func (p *sniffPattern) match(data []byte) bool {
+	if p.pattern == "" {
+		return false
+	}
+	s := 0
+	for s < len(data) && strings.IndexByte(p.ignore, data[s]) >= 0 {
+		s++
+	}
+	if len(data)-s < len(p.pattern) {
+		return false
+	}
+	for i := 0; i < len(p.pattern); i++ {
+		mask := byte(0xff)
+		if p.mask != "" {
+			mask = p.mask[i]
+		}
+		if data[s+i]&mask != p.pattern[i] {
+			return false
+		}
+	}
+	if p.terminated {
+		end := s + len(p.pattern)
+		return end < len(data) && (data[end] == ' ' || data[end] == '>')
+	}
+	return true
+}
+
+// matchPatterns returns the media type of the first pattern that matches.
+func matchPatterns(data []byte, patterns []sniffPattern) (MediaType, bool) {
+	for i := range patterns {
+		if patterns[i].match(data) {
+			return lookup(patterns[i].name), true
+		}
+	}
+	return MediaType{}, false
+}
+
+// supportedBy returns true if a pattern of the table computes the essence.
+func supportedBy(essence string, patterns []sniffPattern) bool {
+	for _, p := range patterns {
+		if p.name == essence {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAudioVideo recognizes the audio and video formats that the standard
+// describes with an algorithm rather than a pattern.
+func matchAudioVideo(data []byte) (MediaType, bool) {
+	switch {
+	case isMP4(data):
+		return lookup("video/mp4"), true
+	case isWebM(data):
+		return lookup("video/webm"), true
+	case isMP3WithoutID3(data):
+		return lookup("audio/mpeg"), true
+	}
+	return MediaType{}, false
+}
+
+// sniffUnknown implements the rules for identifying an unknown media type.
+func sniffUnknown(data []byte, sniffScriptable bool) MediaType {
+	if sniffScriptable {
+		if m, ok := matchPatterns(data, scriptablePatterns); ok {
+			return m
+		}
+	}
+	for _, patterns := range [][]sniffPattern{unknownPatterns, imagePatterns, audioVideoPatterns} {
+		if m, ok := matchPatterns(data, patterns); ok {
+			return m
+		}
+	}
+	if m, ok := matchAudioVideo(data); ok {
+		return m
+	}
+	if m, ok := matchPatterns(data, archivePatterns); ok {
+		return m
+	}
+	if !hasBinaryDataBytes(data) {
+		return lookup("text/plain")
+	}
+	return lookup("application/octet-stream")
+}
+
+// sniffTextOrBinary implements the rules for distinguishing if a resource is
+// text or binary.
+func sniffTextOrBinary(data []byte) MediaType {
+	if bytes.HasPrefix(data, []byte("\xfe\xff")) || bytes.HasPrefix(data, []byte("\xff\xfe")) ||
+		bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) || !hasBinaryDataBytes(data) {
+		return lookup("text/plain")
+	}
+	return sniffUnknown(data, false)
+}
+
+// hasBinaryDataBytes returns true if data has a binary data byte as defined
+// by the standard: a control other than tab, line feed, form feed, carriage
+// return and escape.
+func hasBinaryDataBytes(data []byte) bool {
+	for _, c := range data {
+		if c <= 0x08 || c == 0x0b || (c >= 0x0e && c <= 0x1a) || (c >= 0x1c && c <= 0x1f) {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffFeedOrHTML implements the rules for distinguishing if a resource is a
+// feed or HTML: content declared as HTML whose root element is an RSS or
+// Atom feed is a feed.
+func sniffFeedOrHTML(supplied MediaType, data []byte) MediaType {
+	s := 0
+	if bytes.HasPrefix(data, []byte("\xef\xbb\xbf")) {
+		s = 3
+	}
+	for {
+		// Skip whitespace to the next tag.
+		for s < len(data) && data[s] != '<' {
+			if strings.IndexByte(whitespaceBytes, data[s]) < 0 {
+				return supplied
+			}
+			s++
+		}
+		if s >= len(data) {
+			return supplied
+		}
+		s++
+		rest := data[s:]
+
+		var end string
+		switch {
+		case bytes.HasPrefix(rest, []byte("!--")):
+			s, end = s+3, "-->"
+		case bytes.HasPrefix(rest, []byte("!")):
+			s, end = s+1, ">"
+		case bytes.HasPrefix(rest, []byte("?")):
+			s, end = s+1, "?>"
+		case bytes.HasPrefix(rest, []byte("rss")):
+			return lookup("application/rss+xml")
+		case bytes.HasPrefix(rest, []byte("feed")):
+			return lookup("application/atom+xml")
+		case bytes.HasPrefix(rest, []byte("rdf:RDF")):
+			rest = rest[7:]
+			if bytes.Contains(rest, []byte("http://purl.org/rss/1.0/")) &&
+				bytes.Contains(rest, []byte("http://www.w3.org/1999/02/22-rdf-syntax-ns#")) {
+				return lookup("application/rss+xml")
+			}
+			return supplied
+		default:
+			return supplied
+		}
+		i := bytes.Index(data[s:], []byte(end))
+		if i < 0 {
+			return supplied
+		}
+		s += i + len(end)
+	}
+}
+
+// isMP4 implements the signature for MP4: an ftyp box whose major or a
+// compatible brand starts with "mp4".
+func isMP4(data []byte) bool {
+	if len(data) < 12 {
+		return false
+	}
+	boxSize := int(binary.BigEndian.Uint32(data))
+	if len(data) < boxSize || boxSize%4 != 0 {
+		return false
+	}
+	if string(data[4:8]) != "ftyp" {
+		return false
+	}
+	if string(data[8:11]) == "mp4" {
+		return true
+	}
+	for i := 16; i < boxSize; i += 4 {
+		if string(data[i:i+3]) == "mp4" {
+			return true
+		}
+	}
+	return false
+}
+
+// isWebM implements the signature for WebM: an EBML header whose DocType
+// element, within the first 38 bytes, is "webm".
+func isWebM(data []byte) bool {
+	if len(data) < 4 || string(data[:4]) != "\x1a\x45\xdf\xa3" {
+		return false
+	}
+	for i := 4; i < len(data) && i < 38; i++ {
+		if i+1 >= len(data) || data[i] != 0x42 || data[i+1] != 0x82 {
+			continue
+		}
+		i += 2
+		if i >= len(data) {
+			break
+		}
+		i += vintSize(data, i)
+		if i >= len(data)-4 {
+			break
+		}
+		// The value may be padded with leading zero bytes.
+		j := i
+		for j < len(data) && data[j] == 0 {
+			j++
+		}
+		if bytes.HasPrefix(data[j:], []byte("webm")) {
+			return true
+		}
+	}
+	return false
+}
+
+// vintSize returns the length in bytes of the EBML variable-length integer
+// at data[i]. As in the standard, the length is bounded by len(data) rather
+// than by the bytes that remain.
+func vintSize(data []byte, i int) int {
+	mask, size := byte(0x80), 1
+	for size < 8 && size < len(data) && data[i]&mask == 0 {
+		mask >>= 1
+		size++
+	}
+	return size
+}
+
+// isMP3WithoutID3 implements the signature for MP3 without an ID3 tag: two
+// consecutive MPEG audio layer III frame headers.
+func isMP3WithoutID3(data []byte) bool {
+	size, ok := mp3FrameSize(data)
+	if !ok || size < 4 || size > len(data) {
+		return false
+	}
+	_, ok = mp3FrameSize(data[size:])
+	return ok
+}
+
+// mp3FrameSize returns the size of the MPEG audio layer III frame whose
+// header starts data, computed as the standard does: its tables pick the
+// bit rates by the low bit of the version field, and halve the scale only for
+// the reserved version 1, so the sizes of most MPEG-1 frames differ from
+// those of the MPEG specification.
+func mp3FrameSize(data []byte) (int, bool) {
+	if len(data) < 4 || data[0] != 0xff || data[1]&0xe0 != 0xe0 {
+		return 0, false
+	}
+	layer := (data[1] & 0x06) >> 1
+	bitrateIndex := (data[2] & 0xf0) >> 4
+	sampleRateIndex := (data[2] & 0x0c) >> 2
+	if layer != 1 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return 0, false
+	}
+
+	var (
+		mp3Rates    = [...]int{0, 32000, 40000, 48000, 56000, 64000, 80000, 96000, 112000, 128000, 160000, 192000, 224000, 256000, 320000}
+		mp25Rates   = [...]int{0, 8000, 16000, 24000, 32000, 40000, 48000, 56000, 64000, 80000, 96000, 112000, 128000, 144000, 160000}
+		sampleRates = [...]int{44100, 48000, 32000}
+	)
+	version := (data[1] & 0x18) >> 3
+	bitrate, scale := mp3Rates[bitrateIndex], 144
+	if version&0x01 != 0 {
+		bitrate = mp25Rates[bitrateIndex]
+	}
+	if version == 1 {
+		scale = 72
+	}
+	size := bitrate * scale / sampleRates[sampleRateIndex]
+	if data[2]&0x02 != 0 {
+		size++
+	}
+	return size, true
+}
@@ -0,0 +1,139 @@
+package mediatypes
+
+import (
+	"strings"
+	"testing"
+)
+
+// mp3Frame returns an MPEG-1 layer III frame header at 128 kbit/s and
+// 44.1 kHz, padded to the size of n bytes.
+func mp3Frame(n int) string {
+	return "\xff\xfb\x90\x00" + strings.Repeat("\x00", n-4)
+}
+
+func TestSniffBrowser(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		data        string
+		opts        []SniffOption
+		want        string
+	}{
+		// Rules for identifying an unknown MIME type.
+		{name: "unknown html", data: "<!DOCTYPE html><p>", want: "text/html"},
+		{name: "unknown html with whitespace", data: " \t\r\n<HTML>", want: "text/html"},
+		{name: "unknown html mixed case", data: "<sCrIpT>alert(1)</script>", want: "text/html"},
+		{name: "unknown html comment", data: "<!-- x -->", want: "text/html"},
+		{name: "unknown tag without terminator", data: "<ab>", want: "text/plain"},
+		{name: "unknown tag at end", data: "<html", want: "text/plain"},
+		{name: "unknown xml", data: "\n<?xml version=\"1.0\"?>", want: "text/xml"},
+		{name: "unknown pdf", data: "%PDF-1.4", want: "application/pdf"},
+		{name: "unknown postscript", data: "%!PS-Adobe-3.0", want: "application/postscript"},
+		{name: "unknown utf-16be", data: "\xfe\xff\x00a", want: "text/plain"},
+		{name: "unknown utf-16le", data: "\xff\xfea\x00", want: "text/plain"},
+		{name: "unknown utf-8 bom", data: "\xef\xbb\xbfhi", want: "text/plain"},
+		{name: "unknown text", data: "Hello, world\n", want: "text/plain"},
+		{name: "unknown binary", data: "\x00\x01\x02", want: "application/octet-stream"},
+		{name: "unknown empty", data: "", want: "text/plain"},
+		{name: "unknown/unknown", contentType: "unknown/unknown", data: "<html>", want: "text/html"},
+		{name: "application/unknown", contentType: "application/unknown", data: "<html>", want: "text/html"},
+		{name: "*/*", contentType: "*/*", data: "<html>", want: "text/html"},
+		{name: "malformed supplied", contentType: "text/", data: "<html>", want: "text/html"},
+		{name: "unknown no-sniff", data: "<html>", opts: []SniffOption{WithNoSniff()}, want: "text/plain"},
+		{name: "unknown no-sniff pdf", data: "%PDF-1.4", opts: []SniffOption{WithNoSniff()}, want: "text/plain"},
+		{name: "unknown no-sniff image", data: "GIF89a", opts: []SniffOption{WithNoSniff()}, want: "image/gif"},
+
+		// Image, audio and video type pattern tables.
+		{name: "icon", data: "\x00\x00\x01\x00\x01\x00", want: "image/x-icon"},
+		{name: "cursor", data: "\x00\x00\x02\x00\x01\x00", want: "image/x-icon"},
+		{name: "bmp", data: "BM\x00\x00", want: "image/bmp"},
+		{name: "gif87a", data: "GIF87a", want: "image/gif"},
+		{name: "webp", data: "RIFF\x10\x00\x00\x00WEBPVP8 ", want: "image/webp"},
+		{name: "png", data: "\x89PNG\r\n\x1a\n", want: "image/png"},
+		{name: "jpeg", data: "\xff\xd8\xff\xe0", want: "image/jpeg"},
+		{name: "aiff", data: "FORM\x00\x00\x00\x00AIFF", want: "audio/aiff"},
+		{name: "mp3 with id3", data: "ID3\x03\x00", want: "audio/mpeg"},
+		{name: "mp3 without id3", data: mp3Frame(261) + mp3Frame(261), want: "audio/mpeg"},
+		{name: "mp3 single frame", data: mp3Frame(261), want: "application/octet-stream"},
+		{name: "mp3 mpeg frame size", data: mp3Frame(417) + mp3Frame(417), want: "application/octet-stream"},
+		{name: "ogg", data: "OggS\x00\x02", want: "application/ogg"},
+		{name: "midi", data: "MThd\x00\x00\x00\x06\x00\x01", want: "audio/midi"},
+		{name: "avi", data: "RIFF\x00\x00\x00\x00AVI LIST", want: "video/avi"},
+		{name: "wave", data: "RIFF\x00\x00\x00\x00WAVEfmt ", want: "audio/wave"},
+		{name: "mp4 major brand", data: "\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00isommp42", want: "video/mp4"},
+		{name: "mp4 compatible brand", data: "\x00\x00\x00\x18ftypisom\x00\x00\x02\x00iso2mp41", want: "video/mp4"},
+		{name: "mp4 short box", data: "\x00\x00\x00\x08ftypmp42", want: "video/mp4"},
+		{name: "not mp4", data: "\x00\x00\x00\x18ftypqt  \x00\x00\x02\x00qt  qt  ", want: "application/octet-stream"},
+		{name: "webm", data: "\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\xf7\x81\x01\x42\xf2\x81\x04\x42\xf3\x81\x08\x42\x82\x84webm\x42\x87\x81\x04", want: "video/webm"},
+		{name: "matroska", data: "\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\xf7\x81\x01\x42\xf2\x81\x04\x42\xf3\x81\x08\x42\x82\x88matroska", want: "application/octet-stream"},
+		{name: "gzip", data: "\x1f\x8b\x08\x00", want: "application/x-gzip"},
+		{name: "zip", data: "PK\x03\x04\x14\x00", want: "application/zip"},
+		{name: "rar", data: "Rar!\x1a\x07\x00\xcf", want: "application/x-rar-compressed"},
+		{name: "fonts are not sniffed", data: "wOFF\x00\x01\x00\x00", want: "application/octet-stream"},
+
+		// Determining the computed MIME type of a resource.
+		{name: "lenient parameters", contentType: "image/png; x", data: "<html>", want: "image/png"},
+		{name: "unterminated quote", contentType: "text/plain;charset=\"utf-8", data: "<html>", want: "text/plain; charset=utf-8"},
+		{name: "invalid parameter skipped", contentType: "text/css; a b=c; charset=utf-8", data: "<html>", want: "text/css; charset=utf-8"},
+		{name: "repeated parameter", contentType: "text/css; charset=utf-8; charset=latin1", data: "<html>", want: "text/css; charset=utf-8"},
+		{name: "whitespace", contentType: " text/css ;charset=utf-8 ", data: "<html>", want: "text/css; charset=utf-8"},
+		{name: "invalid subtype", contentType: "text/c s", data: "<html>", want: "text/html"},
+		{name: "no-sniff", contentType: "text/plain", data: "<html>", opts: []SniffOption{WithNoSniff()}, want: "text/plain"},
+		{name: "supplied kept", contentType: "application/json", data: "<html>", want: "application/json"},
+		{name: "supplied parameters kept", contentType: "text/css; charset=utf-8", data: "<html>", want: "text/css; charset=utf-8"},
+		{name: "apache bug text", contentType: "text/plain", data: "<html>", want: "text/plain"},
+		{name: "apache bug binary", contentType: "text/plain; charset=ISO-8859-1", data: "\x89PNG\r\n\x1a\n", want: "image/png"},
+		{name: "apache bug binary unknown", contentType: "text/plain; charset=UTF-8", data: "\x00\x01", want: "application/octet-stream"},
+		{name: "apache bug html not sniffed", contentType: "text/plain; charset=iso-8859-1", data: "<html>\x00", want: "application/octet-stream"},
+		{name: "apache bug bom", contentType: "text/plain", data: "\xff\xfe\x00\x00", want: "text/plain"},
+		{name: "not apache bug", contentType: "text/plain; charset=utf-8", data: "\x89PNG\r\n\x1a\n", want: "text/plain; charset=utf-8"},
+		{name: "xml kept", contentType: "image/svg+xml", data: "<html>", want: "image/svg+xml"},
+		{name: "image corrected", contentType: "image/png", data: "GIF89a", want: "image/gif"},
+		{name: "image kept", contentType: "image/png", data: "<html>", want: "image/png"},
+		{name: "unsupported image kept", contentType: "image/tiff", data: "GIF89a", want: "image/tiff"},
+		{name: "video corrected", contentType: "video/mp4", data: "\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\x82\x84webm\x00\x00\x00\x00", want: "video/webm"},
+
+		// Rules for distinguishing if a resource is a feed or HTML.
+		{name: "html", contentType: "text/html", data: "<!DOCTYPE html><html>", want: "text/html"},
+		{name: "rss", contentType: "text/html", data: "<?xml version=\"1.0\"?>\n<!-- feed -->\n<rss version=\"2.0\">", want: "application/rss+xml"},
+		{name: "atom", contentType: "text/html", data: "\xef\xbb\xbf<?xml version=\"1.0\"?><feed xmlns=\"http://www.w3.org/2005/Atom\">", want: "application/atom+xml"},
+		{name: "rdf", contentType: "text/html", data: "<rdf:RDF xmlns=\"http://purl.org/rss/1.0/\" xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">", want: "application/rss+xml"},
+		{name: "rdf without rss", contentType: "text/html", data: "<rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">", want: "text/html"},
+		{name: "text before tag", contentType: "text/html", data: "x<rss>", want: "text/html"},
+		{name: "unterminated comment", contentType: "text/html", data: "<!-- <rss>", want: "text/html"},
+
+		// Context-specific sniffing.
+		{name: "image context", contentType: "text/html", data: "\x89PNG\r\n\x1a\n", opts: []SniffOption{WithSniffContext(ContextImage)}, want: "image/png"},
+		{name: "image context xml", contentType: "image/svg+xml", data: "\x89PNG\r\n\x1a\n", opts: []SniffOption{WithSniffContext(ContextImage)}, want: "image/svg+xml"},
+		{name: "image context unmatched", contentType: "image/avif", data: "....", opts: []SniffOption{WithSniffContext(ContextImage)}, want: "image/avif"},
+		{name: "image context undefined", data: "....", opts: []SniffOption{WithSniffContext(ContextImage)}, want: ""},
+		{name: "audio context", contentType: "application/octet-stream", data: mp3Frame(261) + mp3Frame(261), opts: []SniffOption{WithSniffContext(ContextAudioVideo)}, want: "audio/mpeg"},
+		{name: "font context", contentType: "application/octet-stream", data: "wOF2\x00\x01", opts: []SniffOption{WithSniffContext(ContextFont)}, want: "font/woff2"},
+		{name: "embedded opentype", data: strings.Repeat("\x01", 34) + "LP", opts: []SniffOption{WithSniffContext(ContextFont)}, want: "application/vnd.ms-fontobject"},
+		{name: "plugin context undefined", data: "<html>", opts: []SniffOption{WithSniffContext(ContextPlugin)}, want: "application/octet-stream"},
+		{name: "plugin context", contentType: "application/pdf", data: "<html>", opts: []SniffOption{WithSniffContext(ContextPlugin)}, want: "application/pdf"},
+		{name: "style context", contentType: "text/plain", data: "body {}", opts: []SniffOption{WithSniffContext(ContextStyle)}, want: "text/plain"},
+		{name: "script context", data: "alert(1)", opts: []SniffOption{WithSniffContext(ContextScript)}, want: ""},
+		{name: "text track context", contentType: "text/plain", data: "WEBVTT", opts: []SniffOption{WithSniffContext(ContextTextTrack)}, want: "text/vtt"},
+		{name: "cache manifest context", data: "CACHE MANIFEST", opts: []SniffOption{WithSniffContext(ContextCacheManifest)}, want: "text/cache-manifest"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got := SniffBrowser(tt.contentType, []byte(tt.data), tt.opts...)
+				if got.ContentType() != tt.want {
+					t.Errorf("SniffBrowser(%q, %q) = %q, want %q", tt.contentType, tt.data, got.ContentType(), tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestSniffBrowser_ResourceHeader(t *testing.T) {
+	// Only the first 1445 bytes are considered, so the binary byte at the
+	// end does not make the resource binary.
+	data := strings.Repeat("a", resourceHeaderLen) + "\x00"
+	if got := SniffBrowser("", []byte(data)); got.Name() != "text/plain" {
+		t.Errorf("SniffBrowser() = %q, want %q", got.Name(), "text/plain")
+	}
+}
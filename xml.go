@@ -0,0 +1,210 @@
+package mediatypes
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// maxXMLPrologSize is the largest number of bytes read while looking for the
+// root element. It bounds the work spent on long comments and large DOCTYPE
+// internal subsets.
+const maxXMLPrologSize = 64 << 10
+
+// XMLRoot describes the prolog and root element of an XML document.
+type XMLRoot struct {
+	// Name is the name of the root element, including its namespace URI.
+	Name xml.Name
+
+	// Doctype is the content of the DOCTYPE declaration, such as
+	// `svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "..."`, or empty if there is
+	// none.
+	Doctype string
+
+	// Stylesheets lists the xml-stylesheet processing instructions.
+	Stylesheets []XMLStylesheet
+}
+
+// XMLStylesheet is an xml-stylesheet processing instruction.
+type XMLStylesheet struct {
+	// Type is the media type of the stylesheet, such as "text/xsl".
+	Type string
+
+	// Href is the location of the stylesheet.
+	Href string
+}
+
+// ParseXMLRoot reads an XML document up to its root element and returns the
+// root element, DOCTYPE declaration and stylesheet instructions. It reads at
+// most 64 KiB and never expands entities, so it is safe to use on untrusted
+// input.
+func ParseXMLRoot(r io.Reader) (XMLRoot, error) {
+	var root XMLRoot
+	d := xml.NewDecoder(io.LimitReader(r, maxXMLPrologSize))
+	d.Strict = false
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// Element names are ASCII in practice, so any ASCII-compatible
+		// encoding can be read as is.
+		return input, nil
+	}
+	for {
+		token, err := d.RawToken()
+		if err != nil {
+			return XMLRoot{}, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			root.Name = t.Name
+			root.Name.Space = xmlNamespace(t)
+			return root, nil
+		case xml.Directive:
+			if bytes.HasPrefix(t, []byte("DOCTYPE")) {
+				root.Doctype = strings.TrimSpace(string(t[len("DOCTYPE"):]))
+			}
+		case xml.ProcInst:
+			if t.Target == "xml-stylesheet" {
+				root.Stylesheets = append(
+					root.Stylesheets, XMLStylesheet{
+						Type: pseudoAttribute(string(t.Inst), "type"),
+						Href: pseudoAttribute(string(t.Inst), "href"),
+					},
+				)
+			}
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				return XMLRoot{}, errNotXML
+			}
+		}
+	}
+}
+
+// errNotXML is returned by ParseXMLRoot if the content has text before the
+// root element.
+var errNotXML = errors.New("mediatypes: not an XML document")
+
+// xmlNamespace returns the namespace URI of a start element read with
+// RawToken, resolving its prefix against the namespace declarations of the
+// element itself.
+func xmlNamespace(e xml.StartElement) string {
+	for _, a := range e.Attr {
+		if (e.Name.Space == "" && a.Name.Space == "" && a.Name.Local == "xmlns") ||
+			(e.Name.Space != "" && a.Name.Space == "xmlns" && a.Name.Local == e.Name.Space) {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// pseudoAttribute returns the value of a pseudo-attribute of a processing
+// instruction, such as the href in `type="text/xsl" href="style.xsl"`.
+func pseudoAttribute(inst, name string) string {
+	for inst != "" {
+		inst = strings.TrimLeft(inst, " \t\r\n")
+		eq := strings.IndexByte(inst, '=')
+		if eq < 0 || eq+1 >= len(inst) {
+			return ""
+		}
+		key := strings.TrimSpace(inst[:eq])
+		rest := strings.TrimLeft(inst[eq+1:], " \t\r\n")
+		if rest == "" || (rest[0] != '"' && rest[0] != '\'') {
+			return ""
+		}
+		end := strings.IndexByte(rest[1:], rest[0])
+		if end < 0 {
+			return ""
+		}
+		if key == name {
+			return rest[1 : end+1]
+		}
+		inst = rest[end+2:]
+	}
+	return ""
+}
+
+// DetectXML returns the media type of an XML document, such as
+// "image/svg+xml" or "application/atom+xml", from the local name and
+// namespace of its root element or, failing that, its DOCTYPE declaration.
+// It returns false if the content is not XML or the document type is not
+// known. Like ParseXMLRoot, it is safe to use on untrusted input.
+func DetectXML(r io.Reader) (MediaType, bool) {
+	root, err := ParseXMLRoot(r)
+	if err != nil {
+		return MediaType{}, false
+	}
+	if name, ok := xmlRoots[root.Name]; ok {
+		return ByName(name)
+	}
+	for _, hint := range xmlDoctypes {
+		if strings.Contains(root.Doctype, hint.publicID) {
+			return ByName(hint.name)
+		}
+	}
+	// Some vocabularies are commonly used without a namespace.
+	if name, ok := xmlRoots[xml.Name{Local: root.Name.Local}]; ok && root.Name.Space == "" {
+		return ByName(name)
+	}
+	return MediaType{}, false
+}
+
+// xmlRoots maps root elements to media type names.
+var xmlRoots = map[xml.Name]string{
+	{Space: "http://www.w3.org/2005/Atom", Local: "feed"}:                                   "application/atom+xml",
+	{Space: "http://www.w3.org/2005/Atom", Local: "entry"}:                                  "application/atom+xml",
+	{Space: "http://www.w3.org/2007/app", Local: "service"}:                                 "application/atomsvc+xml",
+	{Space: "http://www.w3.org/2007/app", Local: "categories"}:                              "application/atomcat+xml",
+	{Space: "", Local: "rss"}:                                                               "application/rss+xml",
+	{Space: "http://www.w3.org/1999/02/22-rdf-syntax-ns#", Local: "RDF"}:                    "application/rdf+xml",
+	{Space: "http://www.w3.org/2000/svg", Local: "svg"}:                                     "image/svg+xml",
+	{Space: "", Local: "svg"}:                                                               "image/svg+xml",
+	{Space: "http://www.w3.org/1999/xhtml", Local: "html"}:                                  "application/xhtml+xml",
+	{Space: "http://www.w3.org/1998/Math/MathML", Local: "math"}:                            "application/mathml+xml",
+	{Space: "http://www.w3.org/1999/XSL/Transform", Local: "stylesheet"}:                    "application/xslt+xml",
+	{Space: "http://www.w3.org/1999/XSL/Transform", Local: "transform"}:                     "application/xslt+xml",
+	{Space: "http://www.w3.org/2003/05/soap-envelope", Local: "Envelope"}:                   "application/soap+xml",
+	{Space: "http://schemas.xmlsoap.org/wsdl/", Local: "definitions"}:                       "application/wsdl+xml",
+	{Space: "http://www.w3.org/ns/wsdl", Local: "description"}:                              "application/wsdl+xml",
+	{Space: "http://www.opengis.net/kml/2.2", Local: "kml"}:                                 "application/vnd.google-earth.kml+xml",
+	{Space: "http://earth.google.com/kml/2.0", Local: "kml"}:                                "application/vnd.google-earth.kml+xml",
+	{Space: "http://earth.google.com/kml/2.1", Local: "kml"}:                                "application/vnd.google-earth.kml+xml",
+	{Space: "http://earth.google.com/kml/2.2", Local: "kml"}:                                "application/vnd.google-earth.kml+xml",
+	{Space: "http://www.topografix.com/GPX/1/0", Local: "gpx"}:                              "application/gpx+xml",
+	{Space: "http://www.topografix.com/GPX/1/1", Local: "gpx"}:                              "application/gpx+xml",
+	{Space: "http://docbook.org/ns/docbook", Local: "book"}:                                 "application/docbook+xml",
+	{Space: "http://docbook.org/ns/docbook", Local: "article"}:                              "application/docbook+xml",
+	{Space: "http://www.tei-c.org/ns/1.0", Local: "TEI"}:                                    "application/tei+xml",
+	{Space: "urn:oasis:names:tc:xliff:document:1.2", Local: "xliff"}:                        "application/xliff+xml",
+	{Space: "urn:oasis:names:tc:xliff:document:2.0", Local: "xliff"}:                        "application/xliff+xml",
+	{Space: "", Local: "opml"}:                                                              "text/x-opml",
+	{Space: "http://www.w3.org/ns/SMIL", Local: "smil"}:                                     "application/smil+xml",
+	{Space: "http://www.w3.org/ns/ttml", Local: "tt"}:                                       "application/ttml+xml",
+	{Space: "http://xspf.org/ns/0/", Local: "playlist"}:                                     "application/xspf+xml",
+	{Space: "urn:mpeg:dash:schema:mpd:2011", Local: "MPD"}:                                  "application/dash+xml",
+	{Space: "http://www.w3.org/2001/vxml", Local: "vxml"}:                                   "application/voicexml+xml",
+	{Space: "", Local: "X3D"}:                                                               "model/x3d+xml",
+	{Space: "http://www.web3d.org/specifications/x3d-namespace", Local: "X3D"}:              "model/x3d+xml",
+	{Space: "http://www.collada.org/2005/11/COLLADASchema", Local: "COLLADA"}:               "model/vnd.collada+xml",
+	{Space: "", Local: "score-partwise"}:                                                    "application/vnd.recordare.musicxml+xml",
+	{Space: "", Local: "score-timewise"}:                                                    "application/vnd.recordare.musicxml+xml",
+	{Space: "http://www.idpf.org/2007/opf", Local: "package"}:                               "application/oebps-package+xml",
+	{Space: "http://www.daisy.org/z3986/2005/ncx/", Local: "ncx"}:                           "application/x-dtbncx+xml",
+	{Space: "urn:ietf:params:xml:ns:metalink", Local: "metalink"}:                           "application/metalink4+xml",
+	{Space: "http://www.w3.org/2001/XMLSchema", Local: "schema"}:                            "application/xml",
+	{Space: "http://schemas.openxmlformats.org/package/2006/content-types", Local: "Types"}: "application/xml",
+}
+
+// xmlDoctypes maps DOCTYPE public identifiers to media type names, for
+// documents whose root element does not identify them.
+var xmlDoctypes = []struct {
+	publicID string
+	name     string
+}{
+	{publicID: "-//W3C//DTD XHTML", name: "application/xhtml+xml"},
+	{publicID: "-//W3C//DTD SVG", name: "image/svg+xml"},
+	{publicID: "-//W3C//DTD MathML", name: "application/mathml+xml"},
+	{publicID: "-//W3C//DTD SMIL", name: "application/smil+xml"},
+	{publicID: "-//OASIS//DTD DocBook XML", name: "application/docbook+xml"},
+	{publicID: "-//Netscape Communications//DTD RSS", name: "application/rss+xml"},
+	{publicID: "-//Recordare//DTD MusicXML", name: "application/vnd.recordare.musicxml+xml"},
+}
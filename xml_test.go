@@ -0,0 +1,169 @@
+package mediatypes
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectXML(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "atom",
+			data:   `<?xml version="1.0" encoding="utf-8"?><feed xmlns="http://www.w3.org/2005/Atom"><title>x</title></feed>`,
+			want:   "application/atom+xml",
+			wantOk: true,
+		},
+		{
+			name:   "rss",
+			data:   `<?xml version="1.0"?><rss version="2.0"><channel/></rss>`,
+			want:   "application/rss+xml",
+			wantOk: true,
+		},
+		{
+			name:   "prefixed svg",
+			data:   `<s:svg xmlns:s="http://www.w3.org/2000/svg" width="1"/>`,
+			want:   "image/svg+xml",
+			wantOk: true,
+		},
+		{
+			name:   "kml",
+			data:   `<?xml version="1.0" encoding="ISO-8859-1"?><kml xmlns="http://www.opengis.net/kml/2.2"/>`,
+			want:   "application/vnd.google-earth.kml+xml",
+			wantOk: true,
+		},
+		{
+			name:   "xhtml doctype",
+			data:   `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd"><html><body/></html>`,
+			want:   "application/xhtml+xml",
+			wantOk: true,
+		},
+		{
+			name:   "unknown root",
+			data:   `<?xml version="1.0"?><config/>`,
+			wantOk: false,
+		},
+		{
+			name:   "not xml",
+			data:   `hello <b>world</b>`,
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, ok := DetectXML(strings.NewReader(tt.data))
+				if ok != tt.wantOk || got.Name() != tt.want {
+					t.Errorf("DetectXML() = %s, %v, want %s, %v", got.Name(), ok, tt.want, tt.wantOk)
+				}
+			},
+		)
+	}
+}
+
+func TestDetectXML_EntityExpansion(t *testing.T) {
+	// A billion laughs: fully expanded, lol9 is 10^9 times "lol".
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?><!DOCTYPE svg [<!ENTITY lol0 "lol">`)
+	for i := 1; i <= 9; i++ {
+		fmt.Fprintf(&b, `<!ENTITY lol%d "%s">`, i, strings.Repeat(fmt.Sprintf("&lol%d;", i-1), 10))
+	}
+	subset := b.String()
+	root := `]><svg xmlns="http://www.w3.org/2000/svg" a="&lol9;">&lol9;</svg>`
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "small", data: subset + root, want: "image/svg+xml"},
+		{name: "beyond limit", data: subset + strings.Repeat("<!-- lol -->", maxXMLPrologSize) + root},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := &countingReader{r: strings.NewReader(tt.data)}
+				got, ok := DetectXML(r)
+				if got.Name() != tt.want || ok != (tt.want != "") {
+					t.Errorf("DetectXML() = %s, %v, want %s", got.Name(), ok, tt.want)
+				}
+				if r.n > maxXMLPrologSize {
+					t.Errorf("DetectXML() read %d bytes, want at most %d", r.n, maxXMLPrologSize)
+				}
+			},
+		)
+	}
+
+	// The internal subset is returned as written, with its references
+	// intact.
+	got, err := ParseXMLRoot(strings.NewReader(subset + root))
+	if err != nil {
+		t.Fatalf("ParseXMLRoot() error = %v", err)
+	}
+	if want := `<!ENTITY lol9 "` + strings.Repeat("&lol8;", 10) + `">`; !strings.Contains(got.Doctype, want) {
+		t.Errorf("ParseXMLRoot().Doctype = %q, want it to contain %q", got.Doctype, want)
+	}
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestParseXMLRoot(t *testing.T) {
+	got, err := ParseXMLRoot(
+		strings.NewReader(
+			`<?xml version="1.0"?>
+<?xml-stylesheet type="text/xsl" href='feed.xsl'?>
+<!-- comment -->
+<!DOCTYPE rss PUBLIC "-//Netscape Communications//DTD RSS 0.91//EN" "rss-0.91.dtd">
+<rss version="0.91"/>`,
+		),
+	)
+	if err != nil {
+		t.Fatalf("ParseXMLRoot() error = %v", err)
+	}
+	want := XMLRoot{
+		Doctype:     `rss PUBLIC "-//Netscape Communications//DTD RSS 0.91//EN" "rss-0.91.dtd"`,
+		Stylesheets: []XMLStylesheet{{Type: "text/xsl", Href: "feed.xsl"}},
+	}
+	want.Name.Local = "rss"
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseXMLRoot() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseXMLRoot_Limit(t *testing.T) {
+	data := "<!--" + strings.Repeat("x", maxXMLPrologSize) + "--><svg xmlns=\"http://www.w3.org/2000/svg\"/>"
+	if _, err := ParseXMLRoot(strings.NewReader(data)); err == nil {
+		t.Error("ParseXMLRoot() error = nil, want error for a prolog larger than the limit")
+	}
+}
+
+func TestXMLRoots_Registered(t *testing.T) {
+	for root, name := range xmlRoots {
+		if _, ok := ByName(name); !ok {
+			t.Errorf("root %v maps to unknown media type %s", root, name)
+		}
+	}
+	for _, hint := range xmlDoctypes {
+		if _, ok := ByName(hint.name); !ok {
+			t.Errorf("doctype %q maps to unknown media type %s", hint.publicID, hint.name)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package mediatypes
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	// maxMimetypeSize is the largest "mimetype" entry that is read.
+	maxMimetypeSize = 256
+
+	// maxContentTypesSize is the largest "[Content_Types].xml" entry that is
+	// read.
+	maxContentTypesSize = 64 << 10
+)
+
+// zipContents gives access to the entries of a ZIP archive, independent of
+// whether the whole archive or only a prefix of it is available.
+type zipContents struct {
+	// names holds the names of the entries, in archive order.
+	names []string
+
+	// read returns up to limit bytes of the named entry, or false if the
+	// entry cannot be read.
+	read func(name string, limit int64) ([]byte, bool)
+}
+
+// has returns true if the archive contains the named entry.
+func (c zipContents) has(name string) bool {
+	for _, n := range c.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectContainer returns the media type of a ZIP-based container format,
+// such as an OOXML or ODF document, an EPUB book, or a JAR, APK or XPI
+// archive. It reads the central directory of the archive, so it needs the
+// whole archive and its size. If the archive is a valid ZIP archive but not a
+// known container format, DetectContainer returns "application/zip".
+func DetectContainer(r io.ReaderAt, size int64) (MediaType, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return MediaType{}, fmt.Errorf("mediatypes: not a zip archive: %v", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	c := zipContents{
+		read: func(name string, limit int64) ([]byte, bool) {
+			f, ok := files[name]
+			if !ok {
+				return nil, false
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, false
+			}
+			defer rc.Close()
+			data, err := ioutil.ReadAll(io.LimitReader(rc, limit))
+			return data, err == nil
+		},
+	}
+	for _, f := range zr.File {
+		files[f.Name] = f
+		c.names = append(c.names, f.Name)
+	}
+
+	if m, ok := classifyZip(c); ok {
+		return m, nil
+	}
+	return lookup("application/zip"), nil
+}
+
+// detectZipPrefix returns the media type of a ZIP-based container format
+// given only a prefix of the archive. It walks the local file headers, so it
+// recognizes formats that store their identifying entries first, such as ODF
+// and EPUB with their "mimetype" entry.
+func detectZipPrefix(data []byte) (MediaType, bool) {
+	type entry struct {
+		method uint16
+		body   []byte
+	}
+	entries := make(map[string]entry)
+	c := zipContents{
+		read: func(name string, limit int64) ([]byte, bool) {
+			e, ok := entries[name]
+			if !ok || e.body == nil {
+				return nil, false
+			}
+			switch e.method {
+			case zip.Store:
+				if int64(len(e.body)) > limit {
+					return e.body[:limit], true
+				}
+				return e.body, true
+			case zip.Deflate:
+				data, err := ioutil.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(e.body)), limit))
+				return data, err == nil
+			}
+			return nil, false
+		},
+	}
+
+	for offset := 0; offset+30 <= len(data); {
+		header := data[offset:]
+		if binary.LittleEndian.Uint32(header) != 0x04034b50 {
+			break
+		}
+		flags := binary.LittleEndian.Uint16(header[6:])
+		method := binary.LittleEndian.Uint16(header[8:])
+		compressedSize := int(binary.LittleEndian.Uint32(header[18:]))
+		nameLen := int(binary.LittleEndian.Uint16(header[26:]))
+		extraLen := int(binary.LittleEndian.Uint16(header[28:]))
+		if 30+nameLen > len(header) {
+			break
+		}
+		name := string(header[30 : 30+nameLen])
+		c.names = append(c.names, name)
+
+		start := offset + 30 + nameLen + extraLen
+		end := start + compressedSize
+		if flags&0x8 != 0 && start <= len(data) {
+			// With a data descriptor the size is only known after the data.
+			// A stored entry ends where the descriptor begins; for others the
+			// next header cannot be located.
+			i := bytes.Index(data[start:], []byte("PK\x07\x08"))
+			if method != zip.Store || i < 0 {
+				entries[name] = entry{method: method}
+				break
+			}
+			end = start + i
+			entries[name] = entry{method: method, body: data[start:end]}
+			offset = end + 16
+			continue
+		}
+		e := entry{method: method}
+		if end <= len(data) {
+			e.body = data[start:end]
+		}
+		entries[name] = e
+		if end > len(data) {
+			break
+		}
+		offset = end
+	}
+	return classifyZip(c)
+}
+
+// classifyZip returns the container format of a ZIP archive from its
+// entries.
+func classifyZip(c zipContents) (MediaType, bool) {
+	// ODF and EPUB name their media type in a "mimetype" entry. Anyone can
+	// write any name there, so only ZIP-based formats are believed.
+	if data, ok := c.read("mimetype", maxMimetypeSize); ok {
+		if m, ok := ByName(strings.TrimSpace(string(data))); ok && isZipFormat(m) {
+			return m, true
+		}
+	}
+
+	// OOXML names the content type of its main part in
+	// "[Content_Types].xml".
+	if data, ok := c.read("[Content_Types].xml", maxContentTypesSize); ok {
+		if m, ok := ooxmlMediaType(data); ok {
+			return m, true
+		}
+	}
+
+	var name string
+	switch {
+	case c.has("AndroidManifest.xml"):
+		name = "application/vnd.android.package-archive"
+	case c.has("install.rdf") || c.has("META-INF/mozilla.rsa"):
+		name = "application/x-xpinstall"
+	case c.has("META-INF/MANIFEST.MF"):
+		name = "application/java-archive"
+	default:
+		return MediaType{}, false
+	}
+	return ByName(name)
+}
+
+// isZipFormat returns true if m is a ZIP-based format, such as an ODF or
+// EPUB document.
+func isZipFormat(m MediaType) bool {
+	name := strings.ToLower(m.name)
+	return strings.EqualFold(m.format, "application/zip") || isZipContainer(name) ||
+		strings.HasSuffix(name, "+zip")
+}
+
+// ooxmlMediaType returns the media type of an OOXML package from its
+// "[Content_Types].xml" entry. The package type is derived from the content
+// type of the main part: for example
+// "application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"
+// identifies a "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+// package, and "application/vnd.ms-excel.sheet.macroEnabled.main+xml" a
+// "application/vnd.ms-excel.sheet.macroEnabled.12" package.
+func ooxmlMediaType(data []byte) (MediaType, bool) {
+	var types struct {
+		Overrides []struct {
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Override"`
+	}
+	if err := xml.Unmarshal(data, &types); err != nil {
+		return MediaType{}, false
+	}
+	for _, o := range types.Overrides {
+		contentType := strings.ToLower(o.ContentType)
+		var name string
+		switch {
+		case strings.HasSuffix(contentType, ".main+xml"):
+			name = strings.TrimSuffix(contentType, ".main+xml")
+		case strings.HasSuffix(contentType, ".main"):
+			name = strings.TrimSuffix(contentType, ".main")
+		default:
+			continue
+		}
+		if strings.HasPrefix(name, "application/vnd.ms-") {
+			name = strings.Replace(name, "macroenabledtemplate", "macroenabled", 1) + ".12"
+		}
+		if m, ok := ByName(name); ok {
+			return m, true
+		}
+	}
+	return MediaType{}, false
+}
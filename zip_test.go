@@ -0,0 +1,232 @@
+package mediatypes
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+// zipEntry is an entry of a test archive.
+type zipEntry struct {
+	name   string
+	body   string
+	method uint16
+}
+
+// contentTypes returns a "[Content_Types].xml" entry that names the given
+// main part content type.
+func contentTypes(mainContentType string) zipEntry {
+	return zipEntry{
+		name: "[Content_Types].xml",
+		body: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/docProps/app.xml" ContentType="application/vnd.openxmlformats-officedocument.extended-properties+xml"/>
+<Override PartName="/main.xml" ContentType="` + mainContentType + `"/>
+</Types>`,
+		method: zip.Deflate,
+	}
+}
+
+// makeZip returns an archive written by archive/zip.
+func makeZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, e := range entries {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: e.name, Method: e.method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// makeZipPrefix returns the local file headers and data of an archive
+// without data descriptors, the way office suites write them.
+func makeZipPrefix(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, e := range entries {
+		body := []byte(e.body)
+		if e.method == zip.Deflate {
+			var compressed bytes.Buffer
+			fw, _ := flate.NewWriter(&compressed, flate.DefaultCompression)
+			fw.Write(body)
+			fw.Close()
+			body = compressed.Bytes()
+		}
+		header := make([]byte, 30)
+		binary.LittleEndian.PutUint32(header, 0x04034b50)
+		binary.LittleEndian.PutUint16(header[4:], 20)
+		binary.LittleEndian.PutUint16(header[8:], e.method)
+		binary.LittleEndian.PutUint32(header[14:], crc32.ChecksumIEEE([]byte(e.body)))
+		binary.LittleEndian.PutUint32(header[18:], uint32(len(body)))
+		binary.LittleEndian.PutUint32(header[22:], uint32(len(e.body)))
+		binary.LittleEndian.PutUint16(header[26:], uint16(len(e.name)))
+		buf.Write(header)
+		buf.WriteString(e.name)
+		buf.Write(body)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectContainer(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		want    string
+	}{
+		{
+			name:    "docx",
+			entries: []zipEntry{contentTypes("application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml")},
+			want:    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		},
+		{
+			name:    "xlsx",
+			entries: []zipEntry{contentTypes("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml")},
+			want:    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		},
+		{
+			name:    "pptm",
+			entries: []zipEntry{contentTypes("application/vnd.ms-powerpoint.presentation.macroEnabled.main+xml")},
+			want:    "application/vnd.ms-powerpoint.presentation.macroEnabled.12",
+		},
+		{
+			name:    "dotm",
+			entries: []zipEntry{contentTypes("application/vnd.ms-word.template.macroEnabledTemplate.main+xml")},
+			want:    "application/vnd.ms-word.template.macroEnabled.12",
+		},
+		{
+			name: "odt",
+			entries: []zipEntry{
+				{name: "mimetype", body: "application/vnd.oasis.opendocument.text"},
+				{name: "content.xml", body: "<office:document-content/>", method: zip.Deflate},
+			},
+			want: "application/vnd.oasis.opendocument.text",
+		},
+		{
+			name: "epub",
+			entries: []zipEntry{
+				{name: "mimetype", body: "application/epub+zip"},
+				{name: "META-INF/container.xml", body: "<container/>", method: zip.Deflate},
+			},
+			want: "application/epub+zip",
+		},
+		{
+			name: "mimetype names html",
+			entries: []zipEntry{
+				{name: "mimetype", body: "text/html"},
+				{name: "index.html", body: "<script>alert(1)</script>"},
+			},
+			want: "application/zip",
+		},
+		{
+			name:    "mimetype names svg",
+			entries: []zipEntry{{name: "mimetype", body: "image/svg+xml"}},
+			want:    "application/zip",
+		},
+		{
+			name: "apk",
+			entries: []zipEntry{
+				{name: "AndroidManifest.xml", body: "\x03\x00\x08\x00"},
+				{name: "META-INF/MANIFEST.MF", body: "Manifest-Version: 1.0\r\n"},
+			},
+			want: "application/vnd.android.package-archive",
+		},
+		{
+			name: "xpi",
+			entries: []zipEntry{
+				{name: "META-INF/MANIFEST.MF", body: "Manifest-Version: 1.0\r\n"},
+				{name: "META-INF/mozilla.rsa", body: "\x30\x82"},
+			},
+			want: "application/x-xpinstall",
+		},
+		{
+			name:    "jar",
+			entries: []zipEntry{{name: "META-INF/MANIFEST.MF", body: "Manifest-Version: 1.0\r\n"}},
+			want:    "application/java-archive",
+		},
+		{
+			name:    "zip",
+			entries: []zipEntry{{name: "readme.txt", body: "hello"}},
+			want:    "application/zip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				data := makeZip(t, tt.entries...)
+				got, err := DetectContainer(bytes.NewReader(data), int64(len(data)))
+				if err != nil {
+					t.Fatalf("DetectContainer() error = %v", err)
+				}
+				if got.Name() != tt.want {
+					t.Errorf("DetectContainer() = %s, want %s", got.Name(), tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestDetectContainer_NotZip(t *testing.T) {
+	data := []byte("%PDF-1.7")
+	if _, err := DetectContainer(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("DetectContainer() error = nil, want error")
+	}
+}
+
+func TestDetect_ZipPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{
+			name: "odt written with data descriptors",
+			data: makeZip(
+				t,
+				zipEntry{name: "mimetype", body: "application/vnd.oasis.opendocument.spreadsheet"},
+				zipEntry{name: "content.xml", body: "<office:document-content/>", method: zip.Deflate},
+			),
+			want: "application/vnd.oasis.opendocument.spreadsheet",
+		},
+		{
+			name: "docx",
+			data: makeZipPrefix(
+				t,
+				contentTypes("application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"),
+				zipEntry{name: "_rels/.rels", body: "<Relationships/>", method: zip.Deflate},
+			),
+			want: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		},
+		{
+			name: "jar",
+			data: makeZipPrefix(t, zipEntry{name: "META-INF/MANIFEST.MF", body: "Manifest-Version: 1.0\r\n", method: zip.Deflate}),
+			want: "application/java-archive",
+		},
+		{
+			name: "truncated",
+			data: makeZipPrefix(t, contentTypes("application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"))[:60],
+			want: "application/zip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if got := Detect(tt.data); got.Name() != tt.want {
+					t.Errorf("Detect() = %s, want %s", got.Name(), tt.want)
+				}
+			},
+		)
+	}
+}